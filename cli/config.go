@@ -3,27 +3,53 @@ package main
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"os"
+	"regexp"
 	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/sethvargo/go-envconfig"
+	"github.com/woozymasta/dayz-exporter/pkg/bemetrics"
 	"golang.org/x/term"
 	"gopkg.in/yaml.v3"
 )
 
 const defaultConfigPath = "config.yaml"
 
+// bans metrics modes
+const (
+	bansDetailed  = "detailed"  // series for each ban
+	bansAggregate = "aggregate" // low cardinality aggregated metrics
+)
+
 // Config represents the main configuration structure for the exporter.
 type Config struct {
-	Labels  map[string]string `yaml:"labels,omitempty" env:"DAYZ_EXPORTER_LABELS"`
-	Logging Logging           `yaml:"logging,omitempty" env:", prefix=DAYZ_EXPORTER_LOG_"`
-	GeoDB   string            `yaml:"geo_db,omitempty" env:"DAYZ_EXPORTER_GEOIP_DB"`
-	Listen  Listen            `yaml:"listen,omitempty" env:", prefix=DAYZ_EXPORTER_LISTEN_"`
-	Query   Query             `yaml:"query,omitempty" env:", prefix=DAYZ_EXPORTER_QUERY_"`
-	Rcon    Rcon              `yaml:"rcon,omitempty" env:", prefix=DAYZ_EXPORTER_RCON_"`
+	Labels    map[string]string `yaml:"labels,omitempty" env:"DAYZ_EXPORTER_LABELS"`
+	Logging   Logging           `yaml:"logging,omitempty" env:", prefix=DAYZ_EXPORTER_LOG_"`
+	GeoDB     string            `yaml:"geo_db,omitempty" env:"DAYZ_EXPORTER_GEOIP_DB"`
+	GeoASNDB  string            `yaml:"geo_asn_db,omitempty" env:"DAYZ_EXPORTER_GEOIP_ASN_DB"`
+	GeoDigits *int              `yaml:"geo_location_precision,omitempty" env:"DAYZ_EXPORTER_GEOIP_LOCATION_PRECISION, default=1"`
+	GeoReload int               `yaml:"geo_reload_interval,omitempty" env:"DAYZ_EXPORTER_GEOIP_RELOAD_INTERVAL, default=60"`
+	Schema    string            `yaml:"metrics_schema,omitempty" env:"DAYZ_EXPORTER_METRICS_SCHEMA, default=v1"`
+	Listen    Listen            `yaml:"listen,omitempty" env:", prefix=DAYZ_EXPORTER_LISTEN_"`
+	Query     Query             `yaml:"query,omitempty" env:", prefix=DAYZ_EXPORTER_QUERY_"`
+	Rcon      Rcon              `yaml:"rcon,omitempty" env:", prefix=DAYZ_EXPORTER_RCON_"`
+	Reconnect Reconnect         `yaml:"reconnect,omitempty" env:", prefix=DAYZ_EXPORTER_RECONNECT_"`
+	Probe     Probe             `yaml:"probe,omitempty" env:", prefix=DAYZ_EXPORTER_PROBE_"`
+	Privacy   Privacy           `yaml:"privacy,omitempty" env:", prefix=DAYZ_EXPORTER_PRIVACY_"`
+	Servers   []Server          `yaml:"-"`
+}
+
+// Server contains settings for one of several game servers monitored by the exporter,
+// options not set for the server are inherited from the global query, rcon and labels.
+type Server struct {
+	Labels map[string]string `yaml:"labels,omitempty"`
+	Name   string            `yaml:"name"`
+	Query  Query             `yaml:"query,omitempty"`
+	Rcon   Rcon              `yaml:"rcon,omitempty"`
 }
 
 // Listen contains settings for the exporter's HTTP server.
@@ -37,12 +63,20 @@ type Listen struct {
 	ExposeInfo  bool   `yaml:"expose_info,omitempty" env:"EXPOSE_INFO, default=false"`
 	InfoAuth    bool   `yaml:"info_auth,omitempty" env:"INFO_AUTH, default=false"`
 	HealthAuth  bool   `yaml:"health_auth,omitempty" env:"HEALTH_AUTH, default=false"`
+	LiveSources bool   `yaml:"liveness_sources,omitempty" env:"LIVENESS_SOURCES, default=false"`
 }
 
 // Query contains Steam A2S query connection settings.
 type Query struct {
-	IP   string `yaml:"ip,omitempty" env:"IP, default=127.0.0.1"`
-	Port int    `yaml:"port,omitempty" env:"PORT, default=27016"`
+	IP              string `yaml:"ip,omitempty" env:"IP, default=127.0.0.1"`
+	Port            int    `yaml:"port,omitempty" env:"PORT, default=27016"`
+	Interval        int    `yaml:"interval,omitempty" env:"INTERVAL, default=15"`
+	PlayersInterval int    `yaml:"players_interval,omitempty" env:"PLAYERS_INTERVAL, default=15"`
+	RulesInterval   int    `yaml:"rules_interval,omitempty" env:"RULES_INTERVAL, default=300"`
+	Players         bool   `yaml:"expose_players,omitempty" env:"EXPOSE_PLAYERS, default=false"`
+	Mods            bool   `yaml:"expose_mods,omitempty" env:"EXPOSE_MODS, default=false"`
+	PlayerSeries    bool   `yaml:"expose_player_series,omitempty" env:"EXPOSE_PLAYER_SERIES, default=false"`
+	Disabled        bool   `yaml:"disabled,omitempty" env:"DISABLED, default=false"`
 }
 
 // Rcon contains BattleEye RCON connection settings.
@@ -52,8 +86,49 @@ type Rcon struct {
 	KeepaliveTimeout int    `yaml:"keepalive_timeout,omitempty" env:"KEEPALIVE_TIMEOUT, default=30"`
 	DeadlineTimeout  int    `yaml:"deadline_timeout,omitempty" env:"DEADLINE_TIMEOUT, default=5"`
 	Port             int    `yaml:"port,omitempty" env:"PORT, default=2305"`
+	PlayersInterval  int    `yaml:"players_interval,omitempty" env:"PLAYERS_INTERVAL, default=15"`
+	BansInterval     int    `yaml:"bans_interval,omitempty" env:"BANS_INTERVAL, default=300"`
+	BansExpiring     int    `yaml:"bans_expiring_window,omitempty" env:"BANS_EXPIRING_WINDOW, default=86400"`
+	BansReasons      int    `yaml:"bans_reasons_limit,omitempty" env:"BANS_REASONS_LIMIT, default=20"`
+	BansMode         string `yaml:"bans_mode,omitempty" env:"BANS_MODE, default=detailed"`
 	BufferSize       uint16 `yaml:"buffer_size,omitempty" env:"BUFFER_SIZE, default=1024"`
 	Bans             bool   `yaml:"expose_bans,omitempty" env:"EXPOSE_BANS, default=false"`
+	NoLoadBans       bool   `yaml:"load_bans_disabled,omitempty" env:"LOAD_BANS_DISABLED, default=false"`
+	PlayerSeries     bool   `yaml:"expose_player_series,omitempty" env:"EXPOSE_PLAYER_SERIES, default=false"`
+	PingNative       bool   `yaml:"ping_native_histogram,omitempty" env:"PING_NATIVE_HISTOGRAM, default=false"`
+	Disabled         bool   `yaml:"disabled,omitempty" env:"DISABLED, default=false"`
+	KickReasonsOther string `yaml:"kick_reasons_other,omitempty" env:"KICK_REASONS_OTHER"`
+
+	// rules for kick reasons categories, can be set only in YAML config
+	KickReasons []KickReasonRule `yaml:"kick_reasons,omitempty"`
+}
+
+// KickReasonRule maps BattlEye kick reasons matched by regular expression to reason category.
+type KickReasonRule struct {
+	Match  string `yaml:"match"`
+	Reason string `yaml:"reason"`
+}
+
+// Probe contains settings for the /probe endpoint querying A2S of arbitrary servers.
+type Probe struct {
+	Targets []string `yaml:"targets,omitempty" env:"TARGETS"`
+	Timeout int      `yaml:"timeout,omitempty" env:"TIMEOUT, default=5"`
+	Enabled bool     `yaml:"enabled,omitempty" env:"ENABLED, default=false"`
+}
+
+// Privacy contains policies for players personal data in metrics labels and logs:
+// keep, drop, hash with salt or truncate (IP only) to /24 network.
+type Privacy struct {
+	IP   string `yaml:"ip,omitempty" env:"IP, default=keep"`
+	GUID string `yaml:"guid,omitempty" env:"GUID, default=keep"`
+	Name string `yaml:"name,omitempty" env:"NAME, default=keep"`
+	Salt string `yaml:"salt,omitempty" env:"SALT"`
+}
+
+// Reconnect contains exponential backoff settings for restoring lost game server connections.
+type Reconnect struct {
+	InitialDelay int `yaml:"initial_delay,omitempty" env:"INITIAL_DELAY, default=1"`
+	MaxDelay     int `yaml:"max_delay,omitempty" env:"MAX_DELAY, default=60"`
 }
 
 // Logging contains configuration for log output.
@@ -79,6 +154,7 @@ func loadConfig() (*Config, error) {
 	}
 
 	// load config from file if is exists
+	var root yaml.Node
 	if path, ok := getConfigPath(); ok {
 		configFile, err := os.Open(path)
 		if err != nil {
@@ -91,8 +167,10 @@ func loadConfig() (*Config, error) {
 		}()
 
 		decoder := yaml.NewDecoder(configFile)
-		err = decoder.Decode(&config)
-		if err != nil {
+		if err := decoder.Decode(&root); err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		if err := root.Decode(&config); err != nil {
 			return nil, err
 		}
 	}
@@ -105,8 +183,21 @@ func loadConfig() (*Config, error) {
 
 	config.setupLogging()
 
-	if config.Rcon.Password == "" {
-		return nil, errors.New("missing required RCON password")
+	if err := config.setupServers(&root); err != nil {
+		return nil, err
+	}
+
+	// pointer tells zero digits from unset value replaced by default
+	if *config.GeoDigits < 0 || *config.GeoDigits > 4 {
+		return nil, fmt.Errorf("geo location precision must be from 0 to 4 digits, got %d", *config.GeoDigits)
+	}
+
+	if _, err := bemetrics.ParseSchema(config.Schema); err != nil {
+		return nil, err
+	}
+
+	if _, err := config.Privacy.policies(); err != nil {
+		return nil, fmt.Errorf("privacy: %w", err)
 	}
 
 	if config.GeoDB != "" {
@@ -117,10 +208,144 @@ func loadConfig() (*Config, error) {
 		}
 	}
 
+	if config.GeoASNDB != "" {
+		log.Trace().Str("file", config.GeoASNDB).Msg("Try find Geo ASN DB file")
+		if _, err := os.Stat(config.GeoASNDB); err != nil {
+			log.Warn().Str("file", config.GeoASNDB).Msg("Cant open Geo ASN DB file")
+			config.GeoASNDB = ""
+		}
+	}
+
 	log.Trace().Any("config", config).Msg("Config loaded")
 	return &config, nil
 }
 
+// fill list of monitored servers, each server from "servers" section is decoded over global settings,
+// without this section the global settings describe a single server
+func (c *Config) setupServers(root *yaml.Node) error {
+	var nodes []yaml.Node
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		var section struct {
+			Servers []yaml.Node `yaml:"servers"`
+		}
+		if err := root.Content[0].Decode(&section); err != nil {
+			return err
+		}
+		nodes = section.Servers
+	}
+
+	if len(nodes) == 0 {
+		c.Servers = []Server{{Query: c.Query, Rcon: c.Rcon, Labels: c.Labels}}
+	} else {
+		names := make(map[string]struct{}, len(nodes))
+		for i := range nodes {
+			server := Server{Query: c.Query, Rcon: c.Rcon}
+			if err := nodes[i].Decode(&server); err != nil {
+				return fmt.Errorf("server #%d: %w", i, err)
+			}
+
+			if server.Name == "" {
+				return fmt.Errorf("server #%d: missing required name", i)
+			}
+			if server.Name == "mods" {
+				return fmt.Errorf("server #%d: name %q is reserved for /info/mods endpoint", i, server.Name)
+			}
+			if _, ok := names[server.Name]; ok {
+				return fmt.Errorf("server %s: duplicate name", server.Name)
+			}
+			names[server.Name] = struct{}{}
+
+			labels := make(map[string]string, len(c.Labels)+len(server.Labels))
+			for k, v := range c.Labels {
+				labels[k] = v
+			}
+			for k, v := range server.Labels {
+				labels[k] = v
+			}
+			if _, ok := labels[targetLabel]; ok {
+				return fmt.Errorf("server %s: label %q is reserved for server name", server.Name, targetLabel)
+			}
+			server.Labels = labels
+
+			c.Servers = append(c.Servers, server)
+		}
+	}
+
+	for _, server := range c.Servers {
+		if err := server.validate(); err != nil {
+			if server.Name != "" {
+				return fmt.Errorf("server %s: %w", server.Name, err)
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// check server has at least one enabled source and required options for them
+func (s Server) validate() error {
+	if s.Rcon.Disabled && s.Query.Disabled {
+		return errors.New("both RCON and A2S query are disabled")
+	}
+
+	if !s.Rcon.Disabled && s.Rcon.Password == "" {
+		return errors.New("missing required RCON password")
+	}
+
+	if s.Rcon.BansMode != bansDetailed && s.Rcon.BansMode != bansAggregate {
+		return fmt.Errorf("unknown bans mode %q, must be %s or %s", s.Rcon.BansMode, bansDetailed, bansAggregate)
+	}
+
+	if _, err := s.Rcon.kickReasons(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// compile kick reasons rules
+func (r Rcon) kickReasons() ([]bemetrics.KickReason, error) {
+	reasons := make([]bemetrics.KickReason, 0, len(r.KickReasons))
+	for _, rule := range r.KickReasons {
+		if rule.Reason == "" {
+			return nil, fmt.Errorf("kick reason rule %q: missing reason", rule.Match)
+		}
+
+		match, err := regexp.Compile(rule.Match)
+		if err != nil {
+			return nil, fmt.Errorf("kick reason rule %q: %w", rule.Match, err)
+		}
+
+		reasons = append(reasons, bemetrics.KickReason{Match: match, Reason: rule.Reason})
+	}
+
+	return reasons, nil
+}
+
+// parse privacy policies, hash policy requires salt
+func (p Privacy) policies() (bemetrics.Privacy, error) {
+	privacy := bemetrics.Privacy{Salt: p.Salt}
+
+	var err error
+	if privacy.IP, err = bemetrics.ParsePolicy(p.IP, true); err != nil {
+		return privacy, fmt.Errorf("ip: %w", err)
+	}
+	if privacy.GUID, err = bemetrics.ParsePolicy(p.GUID, false); err != nil {
+		return privacy, fmt.Errorf("guid: %w", err)
+	}
+	if privacy.Name, err = bemetrics.ParsePolicy(p.Name, false); err != nil {
+		return privacy, fmt.Errorf("name: %w", err)
+	}
+
+	hash := privacy.IP == bemetrics.PolicyHash || privacy.GUID == bemetrics.PolicyHash || privacy.Name == bemetrics.PolicyHash
+	if hash && p.Salt == "" {
+		return privacy, errors.New("missing required salt for hash policy")
+	}
+
+	return privacy, nil
+}
+
 // get path to configuration file from variables, argument or use default
 func getConfigPath() (string, bool) {
 	if path := os.Getenv("DAYZ_EXPORTER_CONFIG_PATH"); path != "" {
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog/log"
+	"gopkg.in/yaml.v3"
+)
+
+// return config with global settings like after environment defaults
+func testConfig() *Config {
+	return &Config{
+		Labels: map[string]string{"dc": "HV-1", "env": "prod"},
+		Query:  Query{IP: "127.0.0.1", Port: 27016, RulesInterval: 300},
+		Rcon:   Rcon{IP: "127.0.0.1", Port: 2305, Password: "strong", BansMode: bansDetailed, BansInterval: 300},
+	}
+}
+
+// decode YAML document to node like config loader does
+func testConfigNode(t *testing.T, doc string) *yaml.Node {
+	t.Helper()
+
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(doc), &root); err != nil {
+		t.Fatal(err)
+	}
+
+	return &root
+}
+
+// load config from YAML document with environment defaults, global logger is restored after test
+func testLoadConfig(t *testing.T, doc string) (*Config, error) {
+	t.Helper()
+
+	logger := log.Logger
+	t.Cleanup(func() { log.Logger = logger })
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(doc), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DAYZ_EXPORTER_CONFIG_PATH", path)
+	t.Setenv("DAYZ_EXPORTER_LOG_LEVEL", "disabled")
+
+	return loadConfig()
+}
+
+func TestLoadConfigPollIntervals(t *testing.T) {
+	cfg, err := testLoadConfig(t, "rcon:\n  password: strong\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// sources are polled in background by default
+	server := cfg.Servers[0]
+	for name, interval := range map[string]int{
+		"query.interval":         server.Query.Interval,
+		"query.players_interval": server.Query.PlayersInterval,
+		"rcon.players_interval":  server.Rcon.PlayersInterval,
+	} {
+		if interval <= 0 {
+			t.Errorf("expected positive default %s, got %d", name, interval)
+		}
+	}
+
+	cfg, err = testLoadConfig(t, "query:\n  interval: -1\nrcon:\n  password: strong\n  players_interval: -1\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if server := cfg.Servers[0]; server.Query.Interval != -1 || server.Rcon.PlayersInterval != -1 {
+		t.Errorf("expected polling on every scrape, got query %d, rcon %d", server.Query.Interval, server.Rcon.PlayersInterval)
+	}
+}
+
+func TestLoadConfigLocationPrecision(t *testing.T) {
+	tests := []struct {
+		name, doc string
+		digits    int
+		valid     bool
+	}{
+		{name: "default", doc: "", digits: 1, valid: true},
+		{name: "whole degrees", doc: "geo_location_precision: 0\n", digits: 0, valid: true},
+		{name: "max", doc: "geo_location_precision: 4\n", digits: 4, valid: true},
+		{name: "negative", doc: "geo_location_precision: -1\n"},
+		{name: "too precise", doc: "geo_location_precision: 5\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := testLoadConfig(t, tt.doc+"rcon:\n  password: strong\n")
+			if !tt.valid {
+				if err == nil {
+					t.Error("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *cfg.GeoDigits != tt.digits {
+				t.Errorf("expected %d digits, got %d", tt.digits, *cfg.GeoDigits)
+			}
+		})
+	}
+}
+
+func TestSetupServersSingle(t *testing.T) {
+	cfg := testConfig()
+	if err := cfg.setupServers(testConfigNode(t, "rcon:\n  password: strong\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cfg.Servers) != 1 {
+		t.Fatalf("expected single server, got %d", len(cfg.Servers))
+	}
+	server := cfg.Servers[0]
+	if server.Name != "" || server.Query != cfg.Query || server.Rcon.Port != cfg.Rcon.Port || !maps.Equal(server.Labels, cfg.Labels) {
+		t.Errorf("expected global settings for single server, got %+v", server)
+	}
+}
+
+func TestSetupServersInheritance(t *testing.T) {
+	cfg := testConfig()
+	err := cfg.setupServers(testConfigNode(t, `
+servers:
+  - name: main
+  - name: pve
+    labels:
+      env: pve
+      mode: pve
+    query:
+      port: 27116
+      expose_mods: true
+    rcon:
+      port: 2405
+      expose_bans: true
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Servers) != 2 {
+		t.Fatalf("expected 2 servers, got %d", len(cfg.Servers))
+	}
+
+	mainServer, pve := cfg.Servers[0], cfg.Servers[1]
+	if mainServer.Name != "main" || mainServer.Query != cfg.Query || mainServer.Rcon.Port != 2305 || mainServer.Rcon.Bans {
+		t.Errorf("expected global settings for server main, got %+v", mainServer)
+	}
+	if !maps.Equal(mainServer.Labels, cfg.Labels) {
+		t.Errorf("expected global labels for server main, got %v", mainServer.Labels)
+	}
+
+	// set options override global, others are inherited
+	if pve.Query.Port != 27116 || !pve.Query.Mods || pve.Query.IP != "127.0.0.1" || pve.Query.RulesInterval != 300 {
+		t.Errorf("unexpected query settings of server pve: %+v", pve.Query)
+	}
+	if pve.Rcon.Port != 2405 || !pve.Rcon.Bans || pve.Rcon.Password != "strong" || pve.Rcon.BansInterval != 300 {
+		t.Errorf("unexpected rcon settings of server pve: %+v", pve.Rcon)
+	}
+	if labels := map[string]string{"dc": "HV-1", "env": "pve", "mode": "pve"}; !maps.Equal(pve.Labels, labels) {
+		t.Errorf("expected labels %v for server pve, got %v", labels, pve.Labels)
+	}
+
+	// global settings are not changed by servers
+	if cfg.Query.Port != 27016 || cfg.Rcon.Bans || cfg.Labels["env"] != "prod" {
+		t.Errorf("global settings changed: %+v %+v %v", cfg.Query, cfg.Rcon, cfg.Labels)
+	}
+}
+
+func TestSetupServersErrors(t *testing.T) {
+	tests := []struct {
+		name, doc, err string
+		labels         map[string]string
+	}{
+		{
+			name: "missing name",
+			doc:  "servers:\n  - name: main\n  - query:\n      port: 27116\n",
+			err:  "server #1: missing required name",
+		},
+		{
+			name: "duplicate name",
+			doc:  "servers:\n  - name: main\n  - name: main\n",
+			err:  "server main: duplicate name",
+		},
+		{
+			name: "reserved name",
+			doc:  "servers:\n  - name: mods\n",
+			err:  `server #0: name "mods" is reserved`,
+		},
+		{
+			name: "reserved label in server",
+			doc:  "servers:\n  - name: main\n    labels:\n      target: other\n",
+			err:  `server main: label "target" is reserved`,
+		},
+		{
+			name:   "reserved label in global labels",
+			doc:    "servers:\n  - name: main\n",
+			labels: map[string]string{targetLabel: "other"},
+			err:    `server main: label "target" is reserved`,
+		},
+		{
+			name: "invalid server settings",
+			doc:  "servers:\n  - name: main\n    rcon:\n      bans_mode: unknown\n",
+			err:  `server main: unknown bans mode "unknown"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			if tt.labels != nil {
+				cfg.Labels = tt.labels
+			}
+
+			err := cfg.setupServers(testConfigNode(t, tt.doc))
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("expected error %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
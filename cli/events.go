@@ -0,0 +1,30 @@
+package main
+
+import (
+	"github.com/woozymasta/bercon-cli/pkg/bercon"
+	"github.com/woozymasta/dayz-exporter/pkg/bemetrics"
+)
+
+// read BattlEye server messages until RCON connection is closed and update events metrics
+func (c *connection) listen(messages <-chan bercon.PacketEvent) {
+	for message := range messages {
+		event := bemetrics.ParseEvent(message.Data)
+		if event == nil {
+			c.logger.Trace().Bytes("message", message.Data).Msg("Unknown server message")
+			continue
+		}
+
+		c.logger.Debug().Str("type", string(event.Type)).Int("id", event.ID).Str("name", c.privacy.MaskName(event.Name)).Msg("Server event received")
+
+		c.mu.RLock()
+		collector := c.collector
+		c.mu.RUnlock()
+
+		// collector is created after first connection is established
+		if collector != nil {
+			collector.HandleEvent(event)
+		}
+	}
+
+	c.logger.Trace().Msg("Server messages listener stopped")
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rs/zerolog/log"
+)
+
+// label with server name added to all server metrics in multi-server mode
+const targetLabel = "target"
+
+// exporter serves metrics and information of all monitored game servers
+type exporter struct {
+	connections []*connection // connections to game servers in config order
+	exposeInfo  bool          // flag for enable/disable /info json endpoint
+	liveSources bool          // flag for check enabled game server sources on liveness
+}
+
+// create exporter with connection manager for each configured server
+func setupExporter(cfg *Config) (*exporter, error) {
+	var geoBuild *prometheus.GaugeVec
+	if cfg.GeoDB != "" || cfg.GeoASNDB != "" {
+		geoBuild = newGeoBuildMetric(prometheus.DefaultRegisterer)
+	}
+
+	var geo *geoDB
+	if cfg.GeoDB != "" {
+		var err error
+		geo, err = openGeoDB(cfg.GeoDB, geoBuild.WithLabelValues("geo_db"))
+		if err != nil {
+			return nil, fmt.Errorf("open GeoIP DB: %v", err)
+		}
+		log.Trace().Msgf("GeoDB loaded success")
+	}
+
+	var asn *geoDB
+	if cfg.GeoASNDB != "" {
+		var err error
+		asn, err = openGeoDB(cfg.GeoASNDB, geoBuild.WithLabelValues("geo_asn_db"))
+		if err != nil {
+			return nil, fmt.Errorf("open GeoIP ASN DB: %v", err)
+		}
+		log.Trace().Msgf("Geo ASN DB loaded success")
+	}
+
+	if cfg.GeoReload > 0 {
+		for _, db := range []*geoDB{geo, asn} {
+			if db != nil {
+				go db.watch(seconds(cfg.GeoReload), nil)
+			}
+		}
+	}
+
+	e := &exporter{exposeInfo: cfg.Listen.ExposeInfo, liveSources: cfg.Listen.LiveSources}
+	for _, server := range cfg.Servers {
+		e.connections = append(e.connections, setupConnection(server, cfg, geo, asn))
+	}
+
+	return e, nil
+}
+
+// return connection by server name, first connection for empty name
+func (e *exporter) connection(name string) *connection {
+	if name == "" {
+		return e.connections[0]
+	}
+
+	for _, c := range e.connections {
+		if c.name == name {
+			return c
+		}
+	}
+
+	return nil
+}
+
+// http handler for serve metrics of exporter and all servers
+func (e *exporter) metricsHandler() http.Handler {
+	gatherers := prometheus.Gatherers{prometheus.DefaultGatherer}
+	for _, c := range e.connections {
+		gatherers = append(gatherers, c.registry)
+	}
+
+	handler := promhttp.InstrumentMetricHandler(
+		prometheus.DefaultRegisterer,
+		promhttp.HandlerFor(gatherers, promhttp.HandlerOpts{}),
+	)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var wg sync.WaitGroup
+		for _, c := range e.connections {
+			wg.Add(1)
+			go func(c *connection) {
+				defer wg.Done()
+				c.refresh()
+			}(c)
+		}
+		wg.Wait()
+
+		// pass control over the standard handler prometheus
+		handler.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,38 @@
+package main
+
+import "sync"
+
+// flight coalesces concurrent calls into one execution, callers share its result
+type flight struct {
+	call *flightCall
+	mu   sync.Mutex
+}
+
+// flightCall represent a single in-progress execution
+type flightCall struct {
+	err  error
+	done chan struct{}
+}
+
+// run fn or wait for the already running execution and return its error
+func (f *flight) do(fn func() error) error {
+	f.mu.Lock()
+	if call := f.call; call != nil {
+		f.mu.Unlock()
+		<-call.done
+		return call.err
+	}
+
+	call := &flightCall{done: make(chan struct{})}
+	f.call = call
+	f.mu.Unlock()
+
+	call.err = fn()
+
+	f.mu.Lock()
+	f.call = nil
+	f.mu.Unlock()
+	close(call.done)
+
+	return call.err
+}
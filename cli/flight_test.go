@@ -0,0 +1,73 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFlightCoalesces(t *testing.T) {
+	var (
+		f       flight
+		calls   atomic.Int64
+		entered sync.WaitGroup
+		done    sync.WaitGroup
+	)
+	errPoll := errors.New("poll failed")
+	running := make(chan struct{})
+	release := make(chan struct{})
+
+	fn := func() error {
+		if calls.Add(1) == 1 {
+			close(running)
+		}
+		<-release
+		return errPoll
+	}
+
+	// first caller starts execution
+	done.Add(1)
+	go func() {
+		defer done.Done()
+		if err := f.do(fn); !errors.Is(err, errPoll) {
+			t.Errorf("expected shared error, got %v", err)
+		}
+	}()
+	<-running
+
+	// others join running execution
+	const callers = 16
+	errs := make([]error, callers)
+	for i := 0; i < callers; i++ {
+		entered.Add(1)
+		done.Add(1)
+		go func(i int) {
+			defer done.Done()
+			entered.Done()
+			errs[i] = f.do(fn)
+		}(i)
+	}
+	entered.Wait()
+	time.Sleep(20 * time.Millisecond) // let callers reach the wait for running execution
+	close(release)
+	done.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("expected one execution for %d concurrent callers, got %d", callers+1, n)
+	}
+	for i, err := range errs {
+		if !errors.Is(err, errPoll) {
+			t.Errorf("caller %d: expected shared error, got %v", i, err)
+		}
+	}
+
+	// next call after completion runs again
+	if err := f.do(func() error { calls.Add(1); return nil }); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("expected new execution after completion, got %d executions", n)
+	}
+}
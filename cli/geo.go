@@ -0,0 +1,79 @@
+package main
+
+import (
+	"net"
+	"strings"
+
+	"github.com/oschwald/geoip2-golang"
+	"github.com/woozymasta/dayz-exporter/pkg/bemetrics"
+)
+
+// lookup IP address location and autonomous system in GeoIP databases, nil if not found
+func (c *connection) lookupGeo(ip string) *bemetrics.GeoInfo {
+	netIP := net.ParseIP(ip)
+	if netIP == nil {
+		return nil
+	}
+
+	var info bemetrics.GeoInfo
+
+	if c.geo != nil {
+		c.geo.use(func(reader *geoip2.Reader) {
+			if err := lookupLocation(reader, netIP, &info); err != nil {
+				c.logger.Trace().Err(err).Str("ip", c.privacy.MaskIP(ip)).Msg("GeoIP lookup failed")
+			}
+		})
+	}
+
+	if c.asn != nil {
+		c.asn.use(func(reader *geoip2.Reader) {
+			if record, err := reader.ASN(netIP); err == nil {
+				info.ASN = record.AutonomousSystemNumber
+				info.ASOrg = record.AutonomousSystemOrganization
+			} else {
+				c.logger.Trace().Err(err).Str("ip", c.privacy.MaskIP(ip)).Msg("GeoIP ASN lookup failed")
+			}
+		})
+	}
+
+	if info == (bemetrics.GeoInfo{}) {
+		return nil
+	}
+
+	return &info
+}
+
+// fill country and continent, also city, subdivision and coordinates for City database
+func lookupLocation(geo *geoip2.Reader, ip net.IP, info *bemetrics.GeoInfo) error {
+	if !geoCity(geo) {
+		record, err := geo.Country(ip)
+		if err != nil {
+			return err
+		}
+
+		info.Country = record.Country.IsoCode
+		info.Continent = record.Continent.Code
+		return nil
+	}
+
+	record, err := geo.City(ip)
+	if err != nil {
+		return err
+	}
+
+	info.Country = record.Country.IsoCode
+	info.Continent = record.Continent.Code
+	info.City = record.City.Names["en"]
+	if n := len(record.Subdivisions); n > 0 {
+		info.Subdivision = record.Subdivisions[n-1].Names["en"]
+	}
+	info.Latitude = record.Location.Latitude
+	info.Longitude = record.Location.Longitude
+
+	return nil
+}
+
+// check GeoIP database contains cities, like GeoLite2-City
+func geoCity(reader *geoip2.Reader) bool {
+	return strings.Contains(reader.Metadata().DatabaseType, "City")
+}
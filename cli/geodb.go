@@ -0,0 +1,127 @@
+package main
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/oschwald/geoip2-golang"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog/log"
+)
+
+// create and register build time metric of GeoIP databases, labeled by config key (geo_db, geo_asn_db)
+func newGeoBuildMetric(reg prometheus.Registerer) *prometheus.GaugeVec {
+	build := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "dayz_exporter_geoip_build_timestamp_seconds",
+			Help: "Unix time of loaded GeoIP database build.",
+		},
+		[]string{"database"},
+	)
+	reg.MustRegister(build)
+
+	return build
+}
+
+// geoDB is a GeoIP database reloaded when the file changes
+type geoDB struct {
+	reader  *geoip2.Reader   // current database reader
+	build   prometheus.Gauge // database build time metric
+	modTime time.Time        // modification time of loaded file
+	path    string           // path to mmdb file
+	mu      sync.RWMutex     // guards reader, lookups hold it so old reader is closed after them
+	size    int64            // size of loaded file
+}
+
+// open GeoIP database file
+func openGeoDB(path string, build prometheus.Gauge) (*geoDB, error) {
+	g := &geoDB{path: path, build: build}
+	if err := g.load(); err != nil {
+		return nil, err
+	}
+
+	return g, nil
+}
+
+// run fn with current database reader
+func (g *geoDB) use(fn func(reader *geoip2.Reader)) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	fn(g.reader)
+}
+
+// check current database contains cities
+func (g *geoDB) city() (city bool) {
+	g.use(func(reader *geoip2.Reader) { city = geoCity(reader) })
+	return city
+}
+
+// poll database file and reload it when modified, e.g. by geoipupdate, until done is closed
+func (g *geoDB) watch(interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(g.path)
+		if err != nil {
+			log.Warn().Err(err).Str("file", g.path).Msg("Cant check GeoIP DB file")
+			continue
+		}
+
+		g.mu.RLock()
+		changed := !info.ModTime().Equal(g.modTime) || info.Size() != g.size
+		g.mu.RUnlock()
+
+		if changed {
+			if err := g.load(); err != nil {
+				log.Error().Err(err).Str("file", g.path).Msg("Cant reload GeoIP DB, keep using previous one")
+			}
+		}
+	}
+}
+
+// open database file and replace current reader, previous reader is closed after in-flight lookups
+func (g *geoDB) load() error {
+	info, err := os.Stat(g.path)
+	if err != nil {
+		return err
+	}
+
+	reader, err := geoip2.Open(g.path)
+	if err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	previous := g.reader
+	g.reader = reader
+	g.modTime = info.ModTime()
+	g.size = info.Size()
+	g.mu.Unlock()
+
+	// metrics set depends on database type and is chosen on startup
+	metadata := reader.Metadata()
+	if previous != nil && previous.Metadata().DatabaseType != metadata.DatabaseType {
+		log.Warn().Str("file", g.path).Str("previous", previous.Metadata().DatabaseType).Str("database", metadata.DatabaseType).
+			Msg("GeoIP DB type changed, restart exporter to update metrics set")
+	}
+	g.build.Set(float64(metadata.BuildEpoch))
+
+	if previous != nil {
+		if err := previous.Close(); err != nil {
+			log.Warn().Err(err).Str("file", g.path).Msg("Cant close previous GeoIP DB")
+		}
+		log.Info().Str("file", g.path).Str("database", metadata.DatabaseType).
+			Time("build", time.Unix(int64(metadata.BuildEpoch), 0)).Msg("GeoIP DB reloaded") // #nosec G115
+	}
+
+	return nil
+}
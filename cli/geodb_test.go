@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/oschwald/geoip2-golang"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// write empty IPv4 MaxMind database with given type and build time
+func writeTestMMDB(t *testing.T, path, dbType string, epoch uint32) {
+	t.Helper()
+
+	str := func(s string) []byte { return append([]byte{0x40 | byte(len(s))}, s...) }
+	uint16v := func(v byte) []byte { return []byte{0xa1, v} }
+
+	var buf bytes.Buffer
+	buf.Write([]byte{0, 0, 1, 0, 0, 1}) // one node, both records point to "not found"
+	buf.Write(make([]byte, 16))         // data section separator
+	buf.WriteString("\xab\xcd\xefMaxMind.com")
+
+	buf.WriteByte(0xe9) // metadata map with 9 pairs
+	buf.Write(str("binary_format_major_version"))
+	buf.Write(uint16v(2))
+	buf.Write(str("binary_format_minor_version"))
+	buf.WriteByte(0xa0)
+	buf.Write(str("build_epoch"))
+	buf.Write([]byte{0x04, 0x02}) // uint64 of 4 bytes
+	buf.Write(binary.BigEndian.AppendUint32(nil, epoch))
+	buf.Write(str("database_type"))
+	buf.Write(str(dbType))
+	buf.Write(str("description"))
+	buf.WriteByte(0xe0)
+	buf.Write(str("ip_version"))
+	buf.Write(uint16v(4))
+	buf.Write(str("languages"))
+	buf.Write([]byte{0x00, 0x04}) // empty array
+	buf.Write(str("node_count"))
+	buf.Write([]byte{0xc1, 1})
+	buf.Write(str("record_size"))
+	buf.Write(uint16v(24))
+
+	// write to temporary file and rename like geoipupdate does
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGeoDBReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "GeoLite2-Country.mmdb")
+	writeTestMMDB(t, path, "GeoLite2-Country", 1700000000)
+
+	build := newGeoBuildMetric(prometheus.NewRegistry())
+	db, err := openGeoDB(path, build.WithLabelValues("geo_db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := testutil.ToFloat64(build.WithLabelValues("geo_db")); v != 1700000000 {
+		t.Errorf("expected build time 1700000000, got %v", v)
+	}
+	if db.city() {
+		t.Error("expected country database")
+	}
+
+	// lookups continue while database is replaced
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				db.use(func(reader *geoip2.Reader) {
+					if _, err := reader.Country(net.ParseIP("192.0.2.1")); err != nil {
+						t.Errorf("lookup failed: %v", err)
+					}
+				})
+			}
+		}
+	}()
+
+	// watcher must exit before test returns and global logger is restored
+	var logs syncBuffer
+	logger := log.Logger
+	log.Logger = zerolog.New(&logs)
+	defer func() { log.Logger = logger }()
+
+	done := make(chan struct{})
+	var watcher sync.WaitGroup
+	watcher.Add(1)
+	go func() {
+		defer watcher.Done()
+		db.watch(10*time.Millisecond, done)
+	}()
+	defer watcher.Wait()
+	defer close(done)
+	writeTestMMDB(t, path, "GeoLite2-City", 1710000000)
+	if err := os.Chtimes(path, time.Now(), time.Now().Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for testutil.ToFloat64(build.WithLabelValues("geo_db")) != 1710000000 {
+		if time.Now().After(deadline) {
+			t.Fatal("database is not reloaded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	close(stop)
+	wg.Wait()
+
+	if !db.city() {
+		t.Error("expected city database after reload")
+	}
+	if logs.count("GeoIP DB type changed") != 1 {
+		t.Error("expected warning about changed database type")
+	}
+}
@@ -1,23 +1,43 @@
 package main
 
 import (
+	"maps"
+	"slices"
+
 	"github.com/woozymasta/a2s/pkg/a2s"
 	"github.com/woozymasta/dayz-exporter/pkg/bemetrics"
 )
 
-// return base labels from A2S INFO and additional extra labels
+// return base labels from A2S INFO and additional extra labels sorted by key,
+// only extra labels are returned without A2S INFO
 func makeLabels(info *a2s.Info, extraLabels map[string]string) bemetrics.Labels {
-	labels := []bemetrics.Label{
-		{Key: "server", Value: info.Name},
-		{Key: "map", Value: info.Map},
-		{Key: "game", Value: info.Folder},
-		{Key: "os", Value: info.Environment.String()},
-		{Key: "version", Value: info.Version},
+	var labels []bemetrics.Label
+	if info != nil {
+		labels = []bemetrics.Label{
+			{Key: "server", Value: info.Name},
+			{Key: "map", Value: info.Map},
+			{Key: "game", Value: info.Folder},
+			{Key: "os", Value: info.Environment.String()},
+			{Key: "version", Value: info.Version},
+		}
 	}
 
-	for k, v := range extraLabels {
-		labels = append(labels, bemetrics.Label{Key: k, Value: v})
+	// sorted for stable labels order, it is compared on each A2S_INFO update
+	for _, k := range slices.Sorted(maps.Keys(extraLabels)) {
+		labels = append(labels, bemetrics.Label{Key: k, Value: extraLabels[k]})
 	}
 
 	return labels
 }
+
+// return changed labels values in "old -> new" format by label key
+func changedLabels(previous, current bemetrics.Labels) map[string]any {
+	changes := make(map[string]any)
+	for i, label := range current {
+		if i < len(previous) && previous[i].Value != label.Value {
+			changes[label.Key] = previous[i].Value + " -> " + label.Value
+		}
+	}
+
+	return changes
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+	"github.com/woozymasta/a2s/pkg/a2s"
+	"github.com/woozymasta/a2s/pkg/keywords"
+)
+
+// one restart produces several signals (reconnect, game time reset, new version),
+// signals within this window after detected restart are counted as the same restart
+const restartWindow = 5 * time.Minute
+
+// restart reasons used in dayz_server_restarts_total
+const (
+	restartReconnect = "reconnect"  // game server connection lost and established again
+	restartTimeReset = "time_reset" // in-game time jumped back to mission start
+	restartUpdate    = "update"     // game server version changed
+)
+
+// lifecycle tracks game server restarts and uptime from connection events and A2S_INFO
+type lifecycle struct {
+	restarts    *prometheus.CounterVec // restarts count by reason
+	uptime      prometheus.Gauge       // time since server start or first connection
+	lastRestart prometheus.Gauge       // unix time of last detected restart
+	logger      zerolog.Logger         // logger with server name context
+	now         func() time.Time       // clock, replaced in tests
+	started     time.Time              // server start time, zero while server is down
+	restarted   time.Time              // time of last detected restart
+	version     string                 // game server version from last A2S_INFO
+	gameTime    time.Duration          // in-game time from last A2S_INFO
+	mu          sync.Mutex             // guards lifecycle state
+	seen        bool                   // flag for first observation used as baseline
+	hasGameTime bool                   // flag for game time known from last A2S_INFO
+}
+
+// create and register game server lifecycle metrics
+func newLifecycle(reg prometheus.Registerer, logger zerolog.Logger) *lifecycle {
+	lc := &lifecycle{
+		logger: logger,
+		now:    time.Now,
+		restarts: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "dayz_server_restarts_total",
+				Help: "Total count of detected game server restarts.",
+			},
+			[]string{"reason"},
+		),
+		uptime: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "dayz_server_uptime_seconds",
+			Help: "Time since last detected game server restart or first connection in seconds.",
+		}),
+		lastRestart: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "dayz_server_last_restart_timestamp_seconds",
+			Help: "Unix time of last detected game server restart.",
+		}),
+	}
+
+	for _, reason := range []string{restartReconnect, restartTimeReset, restartUpdate} {
+		lc.restarts.WithLabelValues(reason)
+	}
+
+	reg.MustRegister(lc.restarts, lc.uptime, lc.lastRestart)
+
+	return lc
+}
+
+// observe game server state after (re)connect or A2S_INFO poll, info is nil without A2S
+func (lc *lifecycle) observe(info *a2s.Info, reconnected bool) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	now := lc.now()
+
+	var (
+		version     string
+		gameTime    time.Duration
+		hasGameTime bool
+	)
+	if info != nil {
+		version = info.Version
+		if kw := keywords.ParseDayZ(info.Keywords); kw.Time != 0 {
+			gameTime, hasGameTime = kw.Time, true
+		}
+	}
+
+	var reason string
+	switch {
+	case !lc.seen:
+		// baseline, real server start time is unknown
+	case version != "" && lc.version != "" && version != lc.version:
+		reason = restartUpdate
+	case hasGameTime && lc.hasGameTime && timeReset(lc.gameTime, gameTime):
+		reason = restartTimeReset
+	case reconnected:
+		reason = restartReconnect
+	}
+
+	if !lc.seen || lc.started.IsZero() {
+		lc.started = now
+	}
+	lc.seen = true
+	if version != "" {
+		lc.version = version
+	}
+	if hasGameTime {
+		lc.gameTime, lc.hasGameTime = gameTime, true
+	}
+
+	if reason == "" || now.Sub(lc.restarted) < restartWindow {
+		return
+	}
+
+	lc.restarted = now
+	lc.started = now
+	lc.restarts.WithLabelValues(reason).Inc()
+	lc.lastRestart.Set(float64(now.Unix()))
+	lc.logger.Info().Str("reason", reason).Msg("Game server restart detected")
+}
+
+// mark game server down, uptime is not counted until next connection
+func (lc *lifecycle) down() {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	lc.started = time.Time{}
+	lc.uptime.Set(0)
+}
+
+// refresh uptime metric
+func (lc *lifecycle) updateUptime() {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	if !lc.started.IsZero() {
+		lc.uptime.Set(lc.now().Sub(lc.started).Seconds())
+	}
+}
+
+// check in-game time jumped back, except day change from late evening to early morning
+func timeReset(previous, current time.Duration) bool {
+	if current >= previous {
+		return false
+	}
+
+	return !(previous >= 22*time.Hour && current <= 2*time.Hour)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+	"github.com/woozymasta/a2s/pkg/a2s"
+)
+
+func TestLifecycleRestarts(t *testing.T) {
+	now := time.Date(2025, 4, 20, 12, 0, 0, 0, time.UTC)
+	lc := newLifecycle(prometheus.NewRegistry(), zerolog.Nop())
+	lc.now = func() time.Time { return now }
+
+	info := func(version, gameTime string) *a2s.Info {
+		return &a2s.Info{Version: version, Keywords: []string{"battleye", gameTime}}
+	}
+	restarts := func(reason string) float64 {
+		return testutil.ToFloat64(lc.restarts.WithLabelValues(reason))
+	}
+
+	// first connection is baseline
+	lc.observe(info("1.27.1", "08:00"), true)
+	now = now.Add(time.Hour)
+	lc.observe(info("1.27.1", "20:00"), false)
+
+	// day change is not a restart
+	now = now.Add(time.Hour)
+	lc.observe(info("1.27.1", "23:30"), false)
+	now = now.Add(time.Minute)
+	lc.observe(info("1.27.1", "00:10"), false)
+	for _, reason := range []string{restartReconnect, restartTimeReset, restartUpdate} {
+		if v := restarts(reason); v != 0 {
+			t.Fatalf("unexpected %s restarts detected: %v", reason, v)
+		}
+	}
+
+	// crash restart, reconnect and time reset in one window count once
+	now = now.Add(time.Hour)
+	lc.observe(info("1.27.1", "14:00"), false)
+	lc.down()
+	lc.observe(info("1.27.1", "08:00"), true)
+	now = now.Add(time.Minute)
+	lc.observe(info("1.27.1", "08:05"), false)
+	if v := restarts(restartTimeReset); v != 1 {
+		t.Errorf("expected 1 time reset restart, got %v", v)
+	}
+	if v := restarts(restartReconnect); v != 0 {
+		t.Errorf("expected no reconnect restarts, got %v", v)
+	}
+	if v := testutil.ToFloat64(lc.lastRestart); v != float64(now.Add(-time.Minute).Unix()) {
+		t.Errorf("unexpected last restart timestamp %v", v)
+	}
+
+	lc.updateUptime()
+	if v := testutil.ToFloat64(lc.uptime); v != 60 {
+		t.Errorf("expected uptime 60 seconds, got %v", v)
+	}
+
+	// server update after restart window
+	now = now.Add(4 * time.Hour)
+	lc.observe(info("1.28.1", "08:00"), false)
+	if v := restarts(restartUpdate); v != 1 {
+		t.Errorf("expected 1 update restart, got %v", v)
+	}
+}
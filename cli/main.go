@@ -29,7 +29,7 @@ func runApp() {
 		log.Fatal().Err(err).Msg("Load config failed")
 	}
 
-	connection, err := setupConnection(config)
+	exporter, err := setupExporter(config)
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to start application")
 	}
@@ -38,16 +38,24 @@ func runApp() {
 	mux := http.NewServeMux()
 
 	// handle metrics
-	mux.Handle(config.Listen.Endpoint, connection.metricsHandler())
+	mux.Handle(config.Listen.Endpoint, exporter.metricsHandler())
 
 	// handle probes
-	mux.HandleFunc("/", connection.rootHandler)
-	mux.HandleFunc("/health", connection.livenessHandler)
-	mux.HandleFunc("/health/liveness", connection.livenessHandler)
-	mux.HandleFunc("/health/readiness", connection.readinessHandler)
+	mux.HandleFunc("/", exporter.rootHandler)
+	mux.HandleFunc("/health", exporter.livenessHandler)
+	mux.HandleFunc("/health/liveness", exporter.livenessHandler)
+	mux.HandleFunc("/health/readiness", exporter.readinessHandler)
 
 	if config.Listen.ExposeInfo {
-		mux.HandleFunc("/info", connection.infoHandler)
+		exporter.handleInfo(mux)
+	}
+
+	if config.Probe.Enabled {
+		prober, err := newProber(config.Probe)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to setup probe endpoint")
+		}
+		mux.HandleFunc("/probe", prober.probeHandler)
 	}
 
 	var handler http.Handler = mux
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// poller updates metrics from one game server data source
+type poller struct {
+	update      func() error  // collect data and update metrics
+	name        string        // data source name used in logs and labels
+	flight      flight        // coalesce concurrent polls of the source
+	interval    time.Duration // update interval, not positive for update on every scrape
+	lastSuccess atomic.Int64  // unix time in nanoseconds of last successful update
+}
+
+// pollMetrics represent exporter metrics about data source polling
+type pollMetrics struct {
+	age      *prometheus.GaugeVec
+	duration *prometheus.GaugeVec
+}
+
+// create and register polling metrics
+func newPollMetrics(reg prometheus.Registerer) *pollMetrics {
+	pm := &pollMetrics{
+		age: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "dayz_exporter_data_age_seconds",
+				Help: "Age of cached data from game server source in seconds.",
+			},
+			[]string{"source"},
+		),
+		duration: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "dayz_exporter_poll_duration_seconds",
+				Help: "Duration of the last poll of game server source in seconds.",
+			},
+			[]string{"source"},
+		),
+	}
+
+	reg.MustRegister(pm.age, pm.duration)
+
+	return pm
+}
+
+// create data source pollers with intervals from server config
+func (c *connection) setupPollers(server Server) {
+	if !server.Query.Disabled {
+		c.pollers = append(c.pollers, &poller{name: "server", update: c.updateServerMetrics, interval: seconds(server.Query.Interval)})
+
+		if server.Query.Players {
+			c.pollers = append(c.pollers, &poller{name: "sessions", update: c.updateSessionMetrics, interval: seconds(server.Query.PlayersInterval)})
+		}
+
+		if server.Query.Mods {
+			c.pollers = append(c.pollers, &poller{name: "mods", update: c.updateModsMetrics, interval: seconds(server.Query.RulesInterval)})
+		}
+	}
+
+	if !server.Rcon.Disabled {
+		c.pollers = append(c.pollers, &poller{name: "players", update: c.updatePlayersMetrics, interval: seconds(server.Rcon.PlayersInterval)})
+
+		if server.Rcon.Bans {
+			c.bansMetrics = newBansMetrics(c.registerer, c.schema)
+			c.pollers = append(c.pollers, &poller{name: "bans", update: c.updateBansMetrics, interval: seconds(server.Rcon.BansInterval)})
+		}
+	}
+
+	for _, p := range c.pollers {
+		if p.interval > 0 {
+			c.logger.Debug().Str("source", p.name).Dur("interval", p.interval).Msg("Scheduled background polling")
+			go c.runPoller(p)
+		}
+	}
+}
+
+// run poller by schedule while game server is connected
+func (c *connection) runPoller(p *poller) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if !c.isConnected() {
+			continue
+		}
+
+		if err := c.poll(p); err != nil {
+			c.handleError(err, p.name)
+		}
+	}
+}
+
+// run pollers matching filter one by one, stops on first error
+func (c *connection) pollEach(scheduled bool) {
+	for _, p := range c.pollers {
+		if (p.interval > 0) != scheduled {
+			continue
+		}
+
+		if err := c.poll(p); err != nil {
+			c.handleError(err, p.name)
+			return
+		}
+	}
+}
+
+// run single poll and track its duration and result time,
+// concurrent polls of the same source share one run
+func (c *connection) poll(p *poller) error {
+	return p.flight.do(func() error {
+		start := time.Now()
+		err := p.update()
+		c.pollMetrics.duration.WithLabelValues(p.name).Set(time.Since(start).Seconds())
+
+		if err != nil {
+			return err
+		}
+
+		p.lastSuccess.Store(time.Now().UnixNano())
+		c.logger.Trace().Str("source", p.name).Msg("Poll completed")
+
+		return nil
+	})
+}
+
+// refresh age of cached data for each source with successful poll
+func (c *connection) updatePollAge() {
+	for _, p := range c.pollers {
+		if last := p.lastSuccess.Load(); last != 0 {
+			c.pollMetrics.age.WithLabelValues(p.name).Set(time.Since(time.Unix(0, last)).Seconds())
+		}
+	}
+}
+
+// convert config seconds to duration
+func seconds(s int) time.Duration {
+	return time.Duration(s) * time.Second
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+)
+
+func TestPollEachSkipsScheduled(t *testing.T) {
+	var onScrape, scheduled int
+	c := newConnection("")
+	c.logger = zerolog.Nop()
+	c.pollers = []*poller{
+		{name: "scrape", update: func() error { onScrape++; return nil }},
+		{name: "scheduled", update: func() error { scheduled++; return nil }, interval: time.Minute},
+	}
+
+	c.pollEach(false)
+	if onScrape != 1 || scheduled != 0 {
+		t.Errorf("expected only not scheduled source polled, got scrape %d, scheduled %d", onScrape, scheduled)
+	}
+
+	c.pollEach(true)
+	if onScrape != 1 || scheduled != 1 {
+		t.Errorf("expected only scheduled source polled, got scrape %d, scheduled %d", onScrape, scheduled)
+	}
+}
+
+func TestPollAge(t *testing.T) {
+	fail := true
+	c := newConnection("")
+	c.logger = zerolog.Nop()
+	p := &poller{name: "players", update: func() error {
+		if fail {
+			return errors.New("not available")
+		}
+		return nil
+	}}
+	c.pollers = []*poller{p}
+
+	// no data age before first successful poll
+	if err := c.poll(p); err == nil {
+		t.Fatal("expected poll error")
+	}
+	if p.lastSuccess.Load() != 0 {
+		t.Error("last success set by failed poll")
+	}
+	c.updatePollAge()
+	if n := testutil.CollectAndCount(c.pollMetrics.age); n != 0 {
+		t.Errorf("expected no data age metric before successful poll, got %d series", n)
+	}
+	if n := testutil.CollectAndCount(c.pollMetrics.duration); n != 1 {
+		t.Errorf("expected poll duration of failed poll, got %d series", n)
+	}
+
+	fail = false
+	if err := c.poll(p); err != nil {
+		t.Fatal(err)
+	}
+	last := p.lastSuccess.Load()
+	if last == 0 {
+		t.Fatal("last success not set by successful poll")
+	}
+	c.updatePollAge()
+	if age := testutil.ToFloat64(c.pollMetrics.age.WithLabelValues("players")); age < 0 || age > 1 {
+		t.Errorf("unexpected data age %v", age)
+	}
+
+	// failed poll keeps time of previous success
+	fail = true
+	time.Sleep(time.Millisecond)
+	if err := c.poll(p); err == nil {
+		t.Fatal("expected poll error")
+	}
+	if p.lastSuccess.Load() != last {
+		t.Error("last success changed by failed poll")
+	}
+}
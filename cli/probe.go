@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rs/zerolog/log"
+	"github.com/woozymasta/a2s/pkg/a2s"
+	"github.com/woozymasta/dayz-exporter/pkg/bemetrics"
+)
+
+// prober runs A2S queries against allowed arbitrary servers for /probe endpoint
+type prober struct {
+	networks []*net.IPNet // allowed networks
+	hosts    []probeHost  // allowed hosts with optional port
+	timeout  int          // A2S query timeout in seconds
+}
+
+// probeHost is an allowed host name or IP with optional port, zero port allows any
+type probeHost struct {
+	host string
+	port int
+}
+
+// create prober with allowlist of targets from config
+func newProber(cfg Probe) (*prober, error) {
+	p := &prober{timeout: cfg.Timeout}
+
+	for _, target := range cfg.Targets {
+		target = strings.TrimSpace(target)
+		if target == "" {
+			continue
+		}
+
+		if strings.Contains(target, "/") {
+			_, network, err := net.ParseCIDR(target)
+			if err != nil {
+				return nil, fmt.Errorf("probe target %s: %w", target, err)
+			}
+			p.networks = append(p.networks, network)
+			continue
+		}
+
+		host, port, err := splitTarget(target)
+		if err != nil {
+			return nil, fmt.Errorf("probe target %s: %w", target, err)
+		}
+		p.hosts = append(p.hosts, probeHost{host: strings.ToLower(host), port: port})
+	}
+
+	if len(p.networks) == 0 && len(p.hosts) == 0 {
+		return nil, fmt.Errorf("probe targets allowlist is empty")
+	}
+
+	return p, nil
+}
+
+// http handler for probe A2S server from target parameter with fresh metrics registry
+func (p *prober) probeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		log.Debug().Str("method", r.Method).Msg("Method not allowed on probe")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	target := r.URL.Query().Get("target")
+	if target == "" {
+		http.Error(w, "Target parameter is missing", http.StatusBadRequest)
+		return
+	}
+
+	addr, err := p.resolve(target)
+	if err != nil {
+		log.Warn().Err(err).Str("target", target).Msg("Probe target rejected")
+		http.Error(w, fmt.Sprintf("Target %s is not allowed", target), http.StatusForbidden)
+		return
+	}
+
+	registry := prometheus.NewRegistry()
+	probeSuccess := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "probe_success",
+		Help: "Whether the A2S probe of target was successful.",
+	})
+	probeDuration := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "probe_duration_seconds",
+		Help: "Duration of the A2S probe of target in seconds.",
+	})
+	registry.MustRegister(probeSuccess, probeDuration)
+
+	start := time.Now()
+	if err := p.probe(addr, registry); err != nil {
+		log.Debug().Err(err).Str("target", target).Msg("Probe failed")
+	} else {
+		probeSuccess.Set(1)
+	}
+	probeDuration.Set(time.Since(start).Seconds())
+
+	promhttp.HandlerFor(registry, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+}
+
+// query A2S_INFO from server and register server metrics in registry
+func (p *prober) probe(addr *net.UDPAddr, registry *prometheus.Registry) error {
+	query, err := a2s.NewWithAddr(addr)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := query.Close(); err != nil {
+			log.Error().Msg("Cant close probe query connection")
+		}
+	}()
+
+	if p.timeout > 0 {
+		query.SetDeadlineTimeout(p.timeout)
+	}
+
+	info, err := query.GetInfo()
+	if err != nil {
+		return fmt.Errorf("get A2S_INFO: %w", err)
+	}
+
+	collector := bemetrics.NewMetricsCollector(makeLabels(info, nil))
+	collector.InitServerMetrics()
+	collector.RegisterMetricsWith(registry)
+	collector.UpdateServerMetrics(info)
+
+	return nil
+}
+
+// resolve target address and check it in allowlist, resolved address is used
+// for query so allowlist can't be bypassed with DNS changes between check and query
+func (p *prober) resolve(target string) (*net.UDPAddr, error) {
+	host, port, err := splitTarget(target)
+	if err != nil {
+		return nil, err
+	}
+	if port == 0 {
+		return nil, fmt.Errorf("port is required")
+	}
+
+	addr, err := net.ResolveUDPAddr("udp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, allowed := range p.hosts {
+		if allowed.port != 0 && allowed.port != port {
+			continue
+		}
+		if allowed.host == strings.ToLower(host) {
+			return addr, nil
+		}
+		if ip := net.ParseIP(allowed.host); ip != nil && ip.Equal(addr.IP) {
+			return addr, nil
+		}
+	}
+
+	for _, network := range p.networks {
+		if network.Contains(addr.IP) {
+			return addr, nil
+		}
+	}
+
+	return nil, fmt.Errorf("target not in allowlist")
+}
+
+// split host with optional port, zero port returned if it not set
+func splitTarget(target string) (string, int, error) {
+	host, portStr, err := net.SplitHostPort(target)
+	if err != nil {
+		// host without port, IPv6 may be in brackets
+		return strings.Trim(target, "[]"), 0, nil //nolint:nilerr // missing port is allowed
+	}
+
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port <= 0 || port > 65535 {
+		return "", 0, fmt.Errorf("invalid port %s", portStr)
+	}
+
+	return host, port, nil
+}
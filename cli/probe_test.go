@@ -0,0 +1,109 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestNewProber(t *testing.T) {
+	tests := []struct {
+		name    string
+		targets []string
+		valid   bool
+	}{
+		{"networks and hosts", []string{"10.0.0.0/24", "2001:db8::/32", "127.0.0.1:27016", "[::1]:2303", "example.com"}, true},
+		{"blank entries skipped", []string{" 10.0.0.0/24 ", ""}, true},
+		{"invalid network", []string{"10.0.0.0/33"}, false},
+		{"invalid port", []string{"127.0.0.1:99999"}, false},
+		{"empty allowlist", []string{"", " "}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newProber(Probe{Targets: tt.targets})
+			if tt.valid && err != nil {
+				t.Errorf("unexpected error %v", err)
+			}
+			if !tt.valid && err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestProberResolve(t *testing.T) {
+	p, err := newProber(Probe{Targets: []string{
+		"10.0.0.0/24",     // network, any port
+		"2001:db8::/32",   // IPv6 network
+		"127.0.0.1:27016", // host with port
+		"[::1]:2303",      // bracketed IPv6 with port
+		"[fd00::1]",       // bracketed IPv6 without port
+		"LocalHost",       // host name without port, any port
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		target  string
+		allowed bool
+	}{
+		{"10.0.0.5:27016", true},
+		{"10.0.0.5:2303", true},
+		{"10.0.1.5:27016", false},
+		{"[2001:db8::1]:27016", true},
+		{"[2001:db9::1]:27016", false},
+		{"127.0.0.1:27016", true},
+		{"127.0.0.1:27017", false}, // port outside allowlist
+		{"127.0.0.2:27016", false},
+		{"[::1]:2303", true},
+		{"[::1]:2304", false},
+		{"[fd00::1]:27016", true},
+		{"localhost:27016", true},
+		{"LOCALHOST:2303", true}, // host names are case-insensitive
+		{"127.0.0.1", false},     // missing port
+		{"10.0.0.5", false},
+		{"[::1]", false},
+		{"10.0.0.5:0", false},
+		{"10.0.0.5:port", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			addr, err := p.resolve(tt.target)
+			if tt.allowed && err != nil {
+				t.Errorf("expected target allowed, got %v", err)
+			}
+			if !tt.allowed && err == nil {
+				t.Errorf("expected target rejected, got %v", addr)
+			}
+		})
+	}
+}
+
+func TestProbeHandlerRejects(t *testing.T) {
+	p, err := newProber(Probe{Targets: []string{"10.0.0.0/24", "127.0.0.1:27016"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		target string
+		status int
+	}{
+		{"", http.StatusBadRequest},
+		{"10.0.1.5:27016", http.StatusForbidden},
+		{"127.0.0.1:27017", http.StatusForbidden},
+		{"127.0.0.1", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		p.probeHandler(rec, httptest.NewRequest(http.MethodGet, "/probe?target="+url.QueryEscape(tt.target), nil))
+		if rec.Code != tt.status {
+			t.Errorf("target %q: expected status %d, got %d", tt.target, tt.status, rec.Code)
+		}
+	}
+}
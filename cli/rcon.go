@@ -2,226 +2,618 @@ package main
 
 import (
 	"fmt"
-	"net/http"
+	"sync"
+	"sync/atomic"
 
 	"github.com/oschwald/geoip2-golang"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/woozymasta/a2s/pkg/a2s"
+	"github.com/woozymasta/a2s/pkg/a3sb"
 	"github.com/woozymasta/bercon-cli/pkg/beparser"
 	"github.com/woozymasta/bercon-cli/pkg/bercon"
 	"github.com/woozymasta/dayz-exporter/pkg/bemetrics"
 )
 
+// rconClient is the part of bercon.Connection used by exporter
+type rconClient interface {
+	Send(command string) ([]byte, error)
+	IsAlive() bool
+	Close() error
+}
+
+// queryClient is the part of a3sb.Client used by exporter
+type queryClient interface {
+	GetInfo() (*a2s.Info, error)
+	GetPlayers() (*[]a2s.Player, error)
+	GetRulesDayZ() (*a3sb.Rules, error)
+	Close() error
+}
+
 type connection struct {
-	rcon       *bercon.Connection          // connection to BattleEye RCON server
-	query      *a2s.Client                 // connection to A2S Steam Query
-	collector  *bemetrics.MetricsCollector // metrics collector
-	geo        *geoip2.Reader              // reader for geoip DB
-	info       *a2s.Info                   // server information
-	bans       bool                        // flag for enable/disable bans metrics
-	exposeInfo bool                        // flag for enable/disable /info json endpoint
-}
-
-// create connection manager
-func setupConnection(cfg *Config) (*connection, error) {
-	// create connection to BattleEye RCON
-	rcon, err := bercon.Open(fmt.Sprintf("%s:%d", cfg.Rcon.IP, cfg.Rcon.Port), cfg.Rcon.Password)
+	rcon         rconClient                  // connection to BattleEye RCON server
+	query        queryClient                 // connection to A2S Steam Query
+	collector    *bemetrics.MetricsCollector // metrics collector, created after first A2S_INFO
+	geo          *geoDB                      // geoip DB
+	asn          *geoDB                      // geoip ASN DB
+	geoDigits    int                         // decimal digits of players location coordinates
+	info         *a2s.Info                   // server information
+	mods         []a3sb.Mod                  // server mods from last A2S_RULES
+	registry     *prometheus.Registry        // registry for all metrics of the server
+	registerer   prometheus.Registerer       // registerer adding target label in multi-server mode
+	up           prometheus.Gauge            // game server availability metric
+	labelChanges prometheus.Counter          // server labels changes metric
+	pollMetrics  *pollMetrics                // data sources polling metrics
+	lifecycle    *lifecycle                  // game server restarts and uptime tracker
+	bansMetrics  *bansMetrics                // ban list refresh metrics
+	pollers      []*poller                   // data sources updated on scrape or by schedule
+	labels       map[string]string           // extra labels from config
+	privacy      bemetrics.Privacy           // policies for players personal data in labels
+	schema       bemetrics.Schema            // metrics names schema
+	logger       zerolog.Logger              // logger with server name context
+	dial         func() error                // opens game server connections, replaced in tests
+	name         string                      // server name in multi-server mode
+	rconCfg      Rcon                        // RCON settings used for (re)connect
+	queryCfg     Query                       // A2S settings used for (re)connect
+	backoff      Reconnect                   // reconnect backoff settings
+	mu           sync.RWMutex                // guards rcon, query, info, mods, collector, connected and ready
+	rconMu       sync.Mutex                  // serializes RCON command exchanges
+	queryMu      sync.Mutex                  // serializes A2S query exchanges
+	reconnecting atomic.Bool                 // flag for running reconnect loop
+	connected    bool                        // flag for established game server connections
+	ready        bool                        // flag for game server connected at least once
+	bans         bool                        // flag for enable/disable bans metrics
+}
+
+// create connection manager for server, game server connections are established in background
+func setupConnection(server Server, cfg *Config, geoDB, asnDB *geoDB) *connection {
+	// init connection structure
+	connection := newConnection(server.Name)
+	connection.labels = server.Labels
+	connection.rconCfg = server.Rcon
+	connection.queryCfg = server.Query
+	connection.backoff = cfg.Reconnect
+	connection.bans = server.Rcon.Bans
+	connection.geo = geoDB
+	connection.asn = asnDB
+	connection.geoDigits = *cfg.GeoDigits          // set by default on config load
+	connection.privacy, _ = cfg.Privacy.policies() // validated on config load
+	connection.schema, _ = bemetrics.ParseSchema(cfg.Schema)
+
+	connection.setupPollers(server)
+
+	go connection.reconnect()
+
+	return connection
+}
+
+// create connection with own metrics registry, metrics are labeled with server name if it set
+func newConnection(name string) *connection {
+	c := &connection{
+		name:     name,
+		registry: prometheus.NewRegistry(),
+		logger:   log.Logger,
+		up: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "dayz_server_up",
+			Help: "Whether the game server is reachable over enabled RCON and A2S sources (1 for up, 0 for down).",
+		}),
+	}
+
+	c.dial = c.connect
+
+	c.registerer = c.registry
+	if name != "" {
+		c.registerer = prometheus.WrapRegistererWith(prometheus.Labels{targetLabel: name}, c.registry)
+		c.logger = log.With().Str(targetLabel, name).Logger()
+	}
+
+	c.labelChanges = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "dayz_exporter_label_changes_total",
+		Help: "Total count of server labels changes detected from A2S_INFO, like server update or rename.",
+	})
+
+	c.registerer.MustRegister(c.up, c.labelChanges)
+	c.pollMetrics = newPollMetrics(c.registerer)
+	c.lifecycle = newLifecycle(c.registerer, c.logger)
+
+	return c
+}
+
+// create and register metrics collector with labels from first A2S_INFO response,
+// only metrics of enabled sources are initialized
+func (c *connection) setupCollector(info *a2s.Info) *bemetrics.MetricsCollector {
+	if info != nil && info.ID != 221100 && info.ID != 1024020 {
+		c.logger.Error().Msg("Game ID on the server does not match DayZ, this looks like a configuration issue")
+	}
+
+	// create bemetrics metrics collector
+	collector := bemetrics.NewMetricsCollector(makeLabels(info, c.labels))
+	collector.SetPrivacy(c.privacy)
+
+	// initialize metrics
+	if !c.queryCfg.Disabled {
+		collector.InitServerMetrics()
+		if c.queryCfg.Players {
+			collector.InitSessionMetrics()
+			if c.queryCfg.PlayerSeries {
+				collector.InitPlayerSessionMetrics()
+			}
+		}
+		if c.queryCfg.Mods {
+			collector.InitModsMetrics()
+		}
+	}
+	if !c.rconCfg.Disabled {
+		collector.SetPingNativeHistogram(c.rconCfg.PingNative)
+		collector.InitPlayerMetrics()
+		if c.rconCfg.PlayerSeries {
+			collector.InitPlayerPingMetrics()
+		}
+		if c.geo != nil {
+			collector.InitCountryPingMetrics()
+			collector.InitGeoMetrics()
+			if c.geo.city() {
+				collector.InitLocationMetrics()
+				collector.SetLocationPrecision(c.geoDigits)
+			}
+		}
+		if c.asn != nil {
+			collector.InitASNMetrics()
+		}
+		if c.geo != nil || c.asn != nil {
+			collector.SetGeoLookup(c.lookupGeo)
+		}
+		collector.InitPlayerTrackerMetrics()
+		collector.InitEventMetrics()
+
+		// rules are validated on config load
+		reasons, _ := c.rconCfg.kickReasons()
+		collector.SetKickReasons(reasons, c.rconCfg.KickReasonsOther)
+		if c.bans {
+			if c.rconCfg.BansMode == bansAggregate {
+				collector.InitBansAggregateMetrics()
+				collector.SetBanReasonsLimit(c.rconCfg.BansReasons)
+			} else {
+				collector.InitBansMetrics()
+			}
+			collector.SetBansExpiringWindow(seconds(c.rconCfg.BansExpiring))
+		}
+	}
+
+	// register metrics
+	collector.SetSchema(c.schema)
+	collector.RegisterMetricsWith(c.registerer)
+	c.logger.Debug().Msg("Metrics collector initialized")
+
+	return collector
+}
+
+// open connections to enabled sources, BattleEye RCON and Steam A2S Query
+func (c *connection) connect() error {
+	var (
+		rcon        rconClient
+		query       queryClient
+		info        *a2s.Info
+		rconVersion []byte
+		err         error
+	)
+
+	if !c.rconCfg.Disabled {
+		rcon, rconVersion, err = c.openRcon()
+		if err != nil {
+			return err
+		}
+	}
+
+	if !c.queryCfg.Disabled {
+		query, info, err = c.openQuery()
+		if err != nil {
+			if rcon != nil {
+				_ = rcon.Close()
+			}
+			return err
+		}
+	}
+
+	event := c.logger.Info()
+	if info != nil {
+		event.
+			Str("ip", c.queryCfg.IP).
+			Int("query port", c.queryCfg.Port).
+			Str("version", info.Version).
+			Str("name", info.Name).
+			Str("map", info.Map)
+	}
+	if rcon != nil {
+		event.
+			Str("rcon ip", c.rconCfg.IP).
+			Int("rcon port", c.rconCfg.Port).
+			Str("rcon version", string(rconVersion))
+	}
+	event.Msg("Connected to server")
+
+	c.mu.Lock()
+	if c.collector == nil {
+		c.collector = c.setupCollector(info)
+	}
+	c.rcon = rcon
+	c.query = query
+	c.info = info
+	c.connected = true
+	c.ready = true
+	c.mu.Unlock()
+	c.up.Set(1)
+
+	return nil
+}
+
+// open connection to BattleEye RCON and return its version
+func (c *connection) openRcon() (rconClient, []byte, error) {
+	rcon, err := bercon.Open(fmt.Sprintf("%s:%d", c.rconCfg.IP, c.rconCfg.Port), c.rconCfg.Password)
 	if err != nil {
-		return nil, fmt.Errorf("open RCON connection: %v", err)
+		return nil, nil, fmt.Errorf("open RCON connection: %v", err)
 	}
 
 	rconVersion, err := rcon.Send("version")
 	if err != nil {
-		return nil, fmt.Errorf("get RCON version: %v", err)
+		_ = rcon.Close()
+		return nil, nil, fmt.Errorf("get RCON version: %v", err)
 	}
 
 	// setup connection
-	if cfg.Rcon.KeepaliveTimeout != 0 {
-		rcon.SetKeepaliveTimeout(cfg.Rcon.KeepaliveTimeout)
+	if c.rconCfg.KeepaliveTimeout != 0 {
+		rcon.SetKeepaliveTimeout(c.rconCfg.KeepaliveTimeout)
 	}
-	rcon.SetDeadlineTimeout(cfg.Rcon.DeadlineTimeout)
-	rcon.SetBufferSize(cfg.Rcon.BufferSize)
+	rcon.SetDeadlineTimeout(c.rconCfg.DeadlineTimeout)
+	rcon.SetBufferSize(c.rconCfg.BufferSize)
 
 	// start keepalive for BattleEye RCON connections
 	rcon.StartKeepAlive()
 
-	// create connection to Steam A2S Query
-	query, err := a2s.New(cfg.Query.IP, cfg.Query.Port)
+	// server messages must be always read, otherwise RCON listener blocks
+	go c.listen(rcon.Messages)
+
+	return rcon, rconVersion, nil
+}
+
+// open connection to Steam A2S Query and return first A2S_INFO response
+func (c *connection) openQuery() (queryClient, *a2s.Info, error) {
+	client, err := a2s.New(c.queryCfg.IP, c.queryCfg.Port)
 	if err != nil {
-		return nil, fmt.Errorf("open A2S connection: %v", err)
+		return nil, nil, fmt.Errorf("open A2S connection: %v", err)
 	}
+	query := &a3sb.Client{Client: client}
+
 	info, err := query.GetInfo()
 	if err != nil {
-		return nil, fmt.Errorf("get A2S_INFO: %v", err)
+		_ = query.Close()
+		return nil, nil, fmt.Errorf("get A2S_INFO: %v", err)
 	}
 
-	log.Info().
-		Str("ip", cfg.Query.IP).
-		Int("query port", cfg.Query.Port).
-		Str("version", info.Version).
-		Str("name", info.Name).
-		Str("map", info.Map).
-		Int("rcon port", cfg.Rcon.Port).
-		Str("rcon version", string(rconVersion)).
-		Msg("Connected to server")
+	return query, info, nil
+}
 
-	if info.ID != 221100 && info.ID != 1024020 {
-		log.Error().Msg("Game ID on the server does not match DayZ, this looks like a configuration issue")
-	}
+// check enabled sources are alive, RCON by connection state and A2S by last query result
+func (c *connection) alive() error {
+	rcon, query := c.clients()
 
-	// create bemetrics metrics collector
-	collector := bemetrics.NewMetricsCollector(makeLabels(info, cfg.Labels))
+	if !c.rconCfg.Disabled && (rcon == nil || !rcon.IsAlive()) {
+		return fmt.Errorf("BattleEye RCON not connected")
+	}
 
-	var geoDB *geoip2.Reader
-	if cfg.GeoDB != "" {
-		geoDB, err = geoip2.Open(cfg.GeoDB)
-		if err != nil {
-			return nil, fmt.Errorf("open GeoIP DB: %v", err)
-		}
-		log.Trace().Msgf("GeoDB loaded success")
+	if !c.queryCfg.Disabled && (query == nil || !c.isConnected()) {
+		return fmt.Errorf("steam A2S query not available")
 	}
 
-	// init connection structure
-	connection := connection{
-		rcon:       rcon,
-		query:      query,
-		collector:  collector,
-		bans:       cfg.Rcon.Bans,
-		info:       info,
-		geo:        geoDB,
-		exposeInfo: cfg.Listen.ExposeInfo,
+	return nil
+}
+
+// close connections to the game server and mark it as down, returns false if already disconnected
+func (c *connection) disconnect() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.connected {
+		return false
 	}
 
-	// initialize metrics
-	connection.collector.InitServerMetrics()
-	connection.collector.InitPlayerMetrics()
-	if cfg.Rcon.Bans {
-		connection.collector.InitBansMetrics()
+	c.connected = false
+	c.up.Set(0)
+
+	if c.query != nil {
+		if err := c.query.Close(); err != nil {
+			c.logger.Error().Msg("Cant close query connection")
+		}
+	}
+	if c.rcon != nil {
+		if err := c.rcon.Close(); err != nil {
+			c.logger.Error().Msg("Cant close rcon connection")
+		}
 	}
 
-	// register metrics
-	connection.collector.RegisterMetrics()
+	return true
+}
+
+// check game server connections are established
+func (c *connection) isConnected() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.connected
+}
+
+// check game server was connected at least once, later outages are reported by dayz_server_up
+func (c *connection) isReady() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.ready
+}
 
-	return &connection, nil
+// return current game server connections
+func (c *connection) clients() (rconClient, queryClient) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.rcon, c.query
+}
+
+// return last received server information, nil if not connected yet
+func (c *connection) serverInfo() *a2s.Info {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.info
+}
+
+// send command to BattleEye RCON, only one command exchange runs at a time
+func (c *connection) send(command string) ([]byte, error) {
+	rcon, _ := c.clients()
+
+	c.rconMu.Lock()
+	defer c.rconMu.Unlock()
+
+	return rcon.Send(command)
 }
 
 // get and update server metrics from Steam A2S Query
 func (c *connection) updateServerMetrics() error {
-	info, err := c.query.GetInfo()
+	_, query := c.clients()
+
+	c.queryMu.Lock()
+	info, err := query.GetInfo()
+	c.queryMu.Unlock()
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to get A2S info query response")
+		c.logger.Error().Err(err).Msg("Failed to get A2S info query response")
 		return err
 	}
 
+	// recreate series if server renamed, updated or map changed
+	previous := c.collector.Labels()
+	if labels := makeLabels(info, c.labels); c.collector.SetLabels(labels) {
+		c.labelChanges.Inc()
+		c.logger.Info().Fields(changedLabels(previous, labels)).Msg("Server labels changed, metrics recreated")
+	}
+
 	c.collector.UpdateServerMetrics(info)
+	c.lifecycle.observe(info, false)
+
+	c.mu.Lock()
 	c.info = info
-	log.Trace().Msg("Server A2S metrics updated")
+	c.mu.Unlock()
+	c.logger.Trace().Msg("Server A2S metrics updated")
+
+	return nil
+}
+
+// get and update players session metrics from Steam A2S Query
+func (c *connection) updateSessionMetrics() error {
+	_, query := c.clients()
+
+	c.queryMu.Lock()
+	players, err := query.GetPlayers()
+	c.queryMu.Unlock()
+	if err != nil {
+		c.logger.Error().Err(err).Msg("Failed to get A2S players query response")
+		return err
+	}
+
+	c.collector.UpdateSessionMetrics(players)
+	c.logger.Trace().Msg("Players session A2S metrics updated")
 
 	return nil
 }
 
+// get and update server mods metrics from Steam A2S Query rules
+func (c *connection) updateModsMetrics() error {
+	_, query := c.clients()
+
+	c.queryMu.Lock()
+	rules, err := query.GetRulesDayZ()
+	c.queryMu.Unlock()
+	if err != nil {
+		c.logger.Error().Err(err).Msg("Failed to get A2S rules query response")
+		return err
+	}
+
+	c.collector.UpdateModsMetrics(rules)
+
+	c.mu.Lock()
+	previous := c.mods
+	c.mods = rules.Mods
+	c.mu.Unlock()
+
+	if previous != nil && !sameMods(previous, rules.Mods) {
+		c.logger.Info().Int("previous", len(previous)).Int("current", len(rules.Mods)).Msg("Server mods changed")
+	}
+	c.logger.Trace().Msg("Server mods A2S metrics updated")
+
+	return nil
+}
+
+// return server mods from last A2S_RULES
+func (c *connection) serverMods() []a3sb.Mod {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.mods
+}
+
 // get and update players metrics from BattleEye RCON
 func (c *connection) updatePlayersMetrics() error {
-	data, err := c.rcon.Send("players")
+	data, err := c.send("players")
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to send 'players' command")
+		c.logger.Error().Err(err).Msg("Failed to send 'players' command")
 		return err
 	}
 
 	playersData := beparser.Parse(data, "players")
 	if players, ok := playersData.(*beparser.Players); ok {
 		if c.geo != nil {
-			players.SetCountryCode(c.geo)
+			c.geo.use(func(reader *geoip2.Reader) { players.SetCountryCode(reader) })
 		}
 		c.collector.UpdatePlayerMetrics(players)
-		log.Trace().Msg("Player metrics updated")
+		c.logger.Trace().Msg("Player metrics updated")
 
 		return nil
 	}
 
-	log.Warn().Msg("Unexpected data type for 'players' response")
+	c.logger.Warn().Msg("Unexpected data type for 'players' response")
 	return fmt.Errorf("unexpected data type for 'players' response")
 }
 
 // get and update bans metrics from BattleEye RCON
 func (c *connection) updateBansMetrics() error {
-	if !c.bans {
-		log.Debug().Msg("Ban metrics disabled, skipping update")
-		return nil
+	// reload bans.txt on server, can be skipped if bans are managed only over RCON
+	if !c.rconCfg.NoLoadBans {
+		if _, err := c.send("loadBans"); err != nil {
+			c.logger.Error().Err(err).Msg("Failed to send 'loadBans' command")
+			return err
+		}
 	}
 
-	_, err := c.rcon.Send("loadBans")
+	data, err := c.send("bans")
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to send 'loadBans' command")
+		c.logger.Error().Err(err).Msg("Failed to send 'bans' command")
 		return err
 	}
 
-	data, err := c.rcon.Send("bans")
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to send 'bans' command")
-		return err
+	if c.bansMetrics != nil {
+		c.bansMetrics.update(len(data))
 	}
 
 	bansData := beparser.Parse(data, "bans")
 	if bans, ok := bansData.(*beparser.Bans); ok {
 		if c.geo != nil {
-			bans.SetCountryCode(c.geo)
+			c.geo.use(func(reader *geoip2.Reader) { bans.SetCountryCode(reader) })
+		}
+		for _, change := range c.collector.UpdateBansMetrics(bans) {
+			c.logger.Info().
+				Str("action", string(change.Action)).
+				Str("type", change.Type).
+				Str("value", change.Value).
+				Str("reason", change.Reason).
+				Int("minutes left", change.MinutesLeft).
+				Msg("Ban list changed")
 		}
-		c.collector.UpdateBansMetrics(bans)
-		log.Trace().Msg("Bans metrics updated")
+		c.logger.Trace().Msg("Bans metrics updated")
 		return nil
 	}
 
-	log.Warn().Msg("Unexpected data type for 'bans' response")
+	c.logger.Warn().Msg("Unexpected data type for 'bans' response")
 	return fmt.Errorf("unexpected data type for 'bans' response")
 }
 
-// http handler for update metrics for each request
-func (c *connection) metricsHandler() http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		if err := c.updateServerMetrics(); err != nil {
-			c.handleError(w, err, "server")
-			return
-		}
-		if err := c.updatePlayersMetrics(); err != nil {
-			c.handleError(w, err, "player")
-			return
-		}
-		if err := c.updateBansMetrics(); err != nil {
-			c.handleError(w, err, "bans")
-			return
-		}
+// bansMetrics represent exporter metrics about ban list refresh, named by metrics schema
+type bansMetrics struct {
+	refresh []prometheus.Gauge
+	size    []prometheus.Gauge
+}
+
+// create and register ban list refresh metrics, bercon_ prefix is used in v1 schema and dayz_ in v2
+func newBansMetrics(reg prometheus.Registerer, schema bemetrics.Schema) *bansMetrics {
+	var prefixes []string
+	switch schema {
+	case bemetrics.SchemaV2:
+		prefixes = []string{"dayz"}
+	case bemetrics.SchemaBoth:
+		prefixes = []string{"bercon", "dayz"}
+	default:
+		prefixes = []string{"bercon"}
+	}
 
-		log.Debug().Msgf("Metrics updated")
-		// pass control over the standard handler prometheus
-		promhttp.Handler().ServeHTTP(w, r)
+	bm := &bansMetrics{}
+	for _, prefix := range prefixes {
+		refresh := prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: prefix + "_bans_last_refresh_timestamp_seconds",
+			Help: "Unix time of last ban list refresh from BattleEye RCON.",
+		})
+		size := prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: prefix + "_bans_response_size_bytes",
+			Help: "Size of last ban list response from BattleEye RCON in bytes.",
+		})
+		reg.MustRegister(refresh, size)
+
+		bm.refresh = append(bm.refresh, refresh)
+		bm.size = append(bm.size, size)
 	}
+
+	return bm
 }
 
-// error handler in http connection
-func (c *connection) handleError(w http.ResponseWriter, err error, context string) {
-	if err != nil {
-		log.Error().Err(err).Str("context", context).Msg("Error updating metrics")
+// set ban list refresh time to now and size of response
+func (bm *bansMetrics) update(size int) {
+	for _, refresh := range bm.refresh {
+		refresh.SetToCurrentTime()
 	}
+	for _, gauge := range bm.size {
+		gauge.Set(float64(size))
+	}
+}
 
-	defer func() {
-		log.Debug().Msg("Resetting metrics and closing connections")
-		c.collector.ResetMetrics()
+// update sources without schedule and age of cached data before scrape
+func (c *connection) refresh() {
+	if c.isConnected() {
+		c.pollEach(false)
+		c.logger.Debug().Msgf("Metrics updated")
+	} else {
+		c.logger.Debug().Msg("Game server unavailable, skipping metrics update")
+	}
+	c.updatePollAge()
+	c.lifecycle.updateUptime()
+}
 
-		if err := c.query.Close(); err != nil {
-			log.Error().Msg("Cant close query connection")
-		}
-		if err := c.rcon.Close(); err != nil {
-			log.Error().Msg("Cant close rcon connection")
-		}
-		if c.geo != nil {
-			if err := c.geo.Close(); err != nil {
-				log.Error().Msg("Cant close geo ip database file")
-			}
+// error handler for failed metrics update
+func (c *connection) handleError(err error, context string) {
+	if !c.disconnect() {
+		c.logger.Debug().Err(err).Str("context", context).Msg("Game server already disconnected")
+		return
+	}
+
+	c.logger.Error().Err(err).Str("context", context).Msg("Error updating metrics, game server looks unavailable")
+	c.logger.Debug().Msg("Resetting metrics and closing connections")
+	c.collector.ResetMetrics()
+	c.lifecycle.down()
+
+	go c.reconnect()
+}
+
+// check both lists contain same mods with same hashes
+func sameMods(a, b []a3sb.Mod) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	mods := make(map[a3sb.Mod]struct{}, len(a))
+	for _, mod := range a {
+		mods[mod] = struct{}{}
+	}
+	for _, mod := range b {
+		if _, ok := mods[mod]; !ok {
+			return false
 		}
-	}()
+	}
 
-	http.Error(w, fmt.Sprintf("Error updating metrics (%s)", context), http.StatusInternalServerError)
-	log.Fatal().Msgf("Failed to update metrics (%s)", context)
+	return true
 }
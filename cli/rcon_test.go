@@ -0,0 +1,273 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/woozymasta/a2s/pkg/a2s"
+	"github.com/woozymasta/a2s/pkg/a3sb"
+	"github.com/woozymasta/dayz-exporter/pkg/bemetrics"
+)
+
+const testPlayers = `Players on server:
+[#] [IP Address]:[Port] [Ping] [GUID] [Name]
+--------------------------------------------------
+0  175.78.137.224:46534    33    20501A3C348F41D8B7AC3F4D1BB2B11C(OK) Avtonom Fedenko
+1  162.47.104.77:45539     298   A3333BB4AFBC64F07F1FA0C6C09E6746(OK) Svitlogor Zelinka
+2  181.238.97.213:37703    285   DA55E95D18536F77A14C0EC70562CB20(OK) Sergiy Filevich (Lobby)
+(3 players in total)
+`
+
+// tracker counts calls, calls overlapping in time and closes
+type tracker struct {
+	calls    atomic.Int64
+	active   atomic.Int64
+	overlaps atomic.Int64
+	closes   atomic.Int64
+}
+
+func (t *tracker) enter() {
+	t.calls.Add(1)
+	if t.active.Add(1) > 1 {
+		t.overlaps.Add(1)
+	}
+	time.Sleep(time.Millisecond)
+}
+
+func (t *tracker) leave() {
+	t.active.Add(-1)
+}
+
+const testBans = `GUID Bans:
+[#] [GUID] [Minutes left] [Reason]
+----------------------------------------
+0  11111111111122222222222223333333 163901 cheater
+
+IP Bans:
+[#] [IP Address] [Minutes left] [Reason]
+----------------------------------------------
+1 8.8.8.8         perm      toxic
+`
+
+// fake BattleEye RCON client
+type fakeRcon struct {
+	tracker
+	commands []string
+	mu       sync.Mutex
+	dead     atomic.Bool
+}
+
+func (f *fakeRcon) Send(command string) ([]byte, error) {
+	f.enter()
+	defer f.leave()
+
+	f.mu.Lock()
+	f.commands = append(f.commands, command)
+	f.mu.Unlock()
+
+	if command == "bans" {
+		return []byte(testBans), nil
+	}
+	return []byte(testPlayers), nil
+}
+
+// count of sent commands
+func (f *fakeRcon) sent(command string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var n int
+	for _, c := range f.commands {
+		if c == command {
+			n++
+		}
+	}
+
+	return n
+}
+
+func (f *fakeRcon) IsAlive() bool { return !f.dead.Load() }
+func (f *fakeRcon) Close() error  { f.closes.Add(1); return nil }
+
+// fake Steam A2S client
+type fakeQuery struct {
+	tracker
+}
+
+func (f *fakeQuery) GetInfo() (*a2s.Info, error) {
+	f.enter()
+	defer f.leave()
+
+	return &a2s.Info{
+		Name:       "Test server",
+		Map:        "chernarusplus",
+		Folder:     "dayz",
+		Version:    "1.27.159674",
+		ID:         221100,
+		Players:    3,
+		MaxPlayers: 60,
+		Keywords:   []string{"battleye", "lqs0", "12:00"},
+		Ping:       10 * time.Millisecond,
+	}, nil
+}
+
+func (f *fakeQuery) GetPlayers() (*[]a2s.Player, error) {
+	f.enter()
+	defer f.leave()
+
+	return &[]a2s.Player{
+		{Name: "Survivor", Duration: 95 * time.Minute, Score: 3},
+		{Name: "Survivor", Duration: 12 * time.Second, Index: 1},
+	}, nil
+}
+
+func (f *fakeQuery) GetRulesDayZ() (*a3sb.Rules, error) {
+	f.enter()
+	defer f.leave()
+
+	return &a3sb.Rules{
+		Mods: []a3sb.Mod{{Name: "Community Framework", ID: 1559212036, Hash: 0x1a2b3c4d}},
+	}, nil
+}
+
+func (f *fakeQuery) Close() error { f.closes.Add(1); return nil }
+
+func TestConcurrentHandlers(t *testing.T) {
+	logger := log.Logger
+	log.Logger = log.Level(zerolog.WarnLevel)
+	defer func() { log.Logger = logger }()
+
+	rcon, query := &fakeRcon{}, &fakeQuery{}
+	info, _ := query.GetInfo()
+
+	c := newConnection("")
+	c.rcon = rcon
+	c.query = query
+	c.info = info
+	c.connected = true
+	c.queryCfg = Query{Players: true, PlayerSeries: true, Mods: true}
+	c.collector = c.setupCollector(info)
+	c.setupPollers(Server{Query: c.queryCfg})
+	c.refresh()
+
+	e := &exporter{connections: []*connection{c}, exposeInfo: true}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", e.metricsHandler())
+	mux.HandleFunc("/info", e.infoHandler)
+	mux.HandleFunc("/info/mods", e.modsHandler)
+	mux.HandleFunc("/", e.rootHandler)
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	const workers, requests = 8, 20
+	var wg sync.WaitGroup
+
+	for i := 0; i < workers; i++ {
+		for _, path := range []string{"/metrics", "/info", "/info/mods", "/"} {
+			wg.Add(1)
+			go func(path string) {
+				defer wg.Done()
+
+				for j := 0; j < requests; j++ {
+					resp, err := http.Get(server.URL + path)
+					if err != nil {
+						t.Errorf("GET %s: %v", path, err)
+						return
+					}
+					_, _ = io.Copy(io.Discard, resp.Body)
+					_ = resp.Body.Close()
+
+					if resp.StatusCode != http.StatusOK {
+						t.Errorf("GET %s: unexpected status %d", path, resp.StatusCode)
+						return
+					}
+				}
+			}(path)
+		}
+	}
+	wg.Wait()
+
+	if n := query.overlaps.Load(); n != 0 {
+		t.Errorf("A2S queries not coalesced, %d overlapping queries", n)
+	}
+	if n := rcon.overlaps.Load(); n != 0 {
+		t.Errorf("RCON commands not serialized, %d overlapping commands", n)
+	}
+	// each scrape polls three A2S sources, concurrent scrapes must share polls
+	if n, limit := query.calls.Load(), int64(3*(workers*requests+1)); n >= limit {
+		t.Errorf("A2S polls of concurrent scrapes not coalesced, %d queries for %d scrapes", n, workers*requests)
+	}
+	t.Logf("scrapes: %d, A2S queries: %d, RCON commands: %d", workers*requests, query.calls.Load(), rcon.calls.Load())
+}
+
+func TestLoadBansDisabled(t *testing.T) {
+	for _, noLoadBans := range []bool{false, true} {
+		rcon := &fakeRcon{}
+		c := newConnection("")
+		c.logger = zerolog.Nop()
+		c.rcon = rcon
+		c.bans = true
+		c.rconCfg = Rcon{Bans: true, NoLoadBans: noLoadBans, BansMode: bansDetailed}
+		c.queryCfg = Query{Disabled: true}
+		c.collector = c.setupCollector(nil)
+		c.bansMetrics = newBansMetrics(c.registerer, bemetrics.SchemaV1)
+
+		start := time.Now().Unix()
+		if err := c.updateBansMetrics(); err != nil {
+			t.Fatal(err)
+		}
+
+		expected := 1
+		if noLoadBans {
+			expected = 0
+		}
+		if n := rcon.sent("loadBans"); n != expected {
+			t.Errorf("load_bans_disabled %v: expected %d loadBans commands, got %d", noLoadBans, expected, n)
+		}
+		if n := rcon.sent("bans"); n != 1 {
+			t.Errorf("load_bans_disabled %v: expected 1 bans command, got %d", noLoadBans, n)
+		}
+		if v := testutil.ToFloat64(c.bansMetrics.refresh[0]); v < float64(start) {
+			t.Errorf("load_bans_disabled %v: unexpected refresh timestamp %v", noLoadBans, v)
+		}
+		if v := testutil.ToFloat64(c.bansMetrics.size[0]); v != float64(len(testBans)) {
+			t.Errorf("load_bans_disabled %v: expected response size %d, got %v", noLoadBans, len(testBans), v)
+		}
+	}
+}
+
+func TestBansMetricsSchema(t *testing.T) {
+	tests := []struct {
+		schema bemetrics.Schema
+		v1, v2 int
+	}{
+		{bemetrics.SchemaV1, 2, 0},
+		{bemetrics.SchemaV2, 0, 2},
+		{bemetrics.SchemaBoth, 2, 2},
+	}
+
+	for _, tt := range tests {
+		reg := prometheus.NewRegistry()
+		newBansMetrics(reg, tt.schema).update(len(testBans))
+
+		v1, err := testutil.GatherAndCount(reg, "bercon_bans_last_refresh_timestamp_seconds", "bercon_bans_response_size_bytes")
+		if err != nil || v1 != tt.v1 {
+			t.Errorf("schema %s: expected %d v1 metrics, got %d, %v", tt.schema, tt.v1, v1, err)
+		}
+		v2, err := testutil.GatherAndCount(reg, "dayz_bans_last_refresh_timestamp_seconds", "dayz_bans_response_size_bytes")
+		if err != nil || v2 != tt.v2 {
+			t.Errorf("schema %s: expected %d v2 metrics, got %d, %v", tt.schema, tt.v2, v2, err)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"time"
+)
+
+// establish game server connections with exponential backoff, only one loop runs at a time
+func (c *connection) reconnect() {
+	for {
+		if !c.reconnecting.CompareAndSwap(false, true) {
+			c.logger.Trace().Msg("Reconnect already in progress")
+			return
+		}
+
+		c.dialWithBackoff()
+		c.reconnecting.Store(false)
+
+		// connection lost before flag was cleared, its error handler could not start new loop
+		if c.isConnected() {
+			break
+		}
+		c.logger.Warn().Msg("Connection to game server lost while reconnecting, retrying")
+	}
+
+	c.lifecycle.observe(c.serverInfo(), true)
+
+	// fill cache of scheduled sources without waiting for the first tick
+	c.pollEach(true)
+}
+
+// try to connect until success, delay between attempts grows up to maximum
+func (c *connection) dialWithBackoff() {
+	delay, maxDelay := c.backoff.delays()
+
+	for attempt := 1; ; attempt++ {
+		err := c.dial()
+		if err == nil {
+			c.logger.Info().Int("attempt", attempt).Msg("Connection to game server established")
+			return
+		}
+
+		c.logger.Warn().Err(err).Int("attempt", attempt).Dur("retry in", delay).Msg("Connection to game server failed")
+		time.Sleep(delay)
+
+		delay *= 2
+		if delay > maxDelay {
+			delay = maxDelay
+		}
+	}
+}
+
+// return initial and maximum reconnect delays with sane fallbacks
+func (r Reconnect) delays() (time.Duration, time.Duration) {
+	initial := time.Duration(r.InitialDelay) * time.Second
+	if initial <= 0 {
+		initial = time.Second
+	}
+
+	maxDelay := time.Duration(r.MaxDelay) * time.Second
+	if maxDelay < initial {
+		maxDelay = initial
+	}
+
+	return initial, maxDelay
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func TestReconnectDelays(t *testing.T) {
+	tests := []struct {
+		name              string
+		cfg               Reconnect
+		initial, maxDelay time.Duration
+	}{
+		{"configured", Reconnect{InitialDelay: 2, MaxDelay: 60}, 2 * time.Second, 60 * time.Second},
+		{"zero initial", Reconnect{InitialDelay: 0, MaxDelay: 30}, time.Second, 30 * time.Second},
+		{"negative initial", Reconnect{InitialDelay: -5, MaxDelay: 30}, time.Second, 30 * time.Second},
+		{"zero max", Reconnect{InitialDelay: 5, MaxDelay: 0}, 5 * time.Second, 5 * time.Second},
+		{"max below initial", Reconnect{InitialDelay: 10, MaxDelay: 3}, 10 * time.Second, 10 * time.Second},
+		{"all unset", Reconnect{}, time.Second, time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			initial, maxDelay := tt.cfg.delays()
+			if initial != tt.initial {
+				t.Errorf("expected initial delay %v, got %v", tt.initial, initial)
+			}
+			if maxDelay != tt.maxDelay {
+				t.Errorf("expected max delay %v, got %v", tt.maxDelay, maxDelay)
+			}
+		})
+	}
+}
+
+// logs writer safe for concurrent use
+type syncBuffer struct {
+	buf bytes.Buffer
+	mu  sync.Mutex
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) count(msg string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return strings.Count(b.buf.String(), msg)
+}
+
+func TestHandleErrorReconnectsOnce(t *testing.T) {
+	var logs syncBuffer
+	rcon, query := &fakeRcon{}, &fakeQuery{}
+
+	c := newConnection("")
+	c.logger = zerolog.New(&logs)
+	c.lifecycle.logger = zerolog.Nop()
+	c.rconCfg.Disabled = true
+	c.queryCfg.Disabled = true
+	c.collector = c.setupCollector(nil)
+	c.rcon = rcon
+	c.query = query
+	c.connected = true
+
+	// connection is restored only after all pollers reported errors
+	var dials atomic.Int64
+	release := make(chan struct{})
+	c.dial = func() error {
+		dials.Add(1)
+		<-release
+
+		c.mu.Lock()
+		c.connected = true
+		c.mu.Unlock()
+
+		return nil
+	}
+
+	// several pollers fail at the same time
+	const pollers = 8
+	var wg sync.WaitGroup
+	for i := 0; i < pollers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.handleError(errors.New("connection lost"), "test")
+		}()
+	}
+	wg.Wait()
+	close(release)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !c.isConnected() || c.reconnecting.Load() {
+		if time.Now().After(deadline) {
+			t.Fatal("connection is not restored")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if n := rcon.closes.Load(); n != 1 {
+		t.Errorf("expected RCON connection closed once, got %d", n)
+	}
+	if n := query.closes.Load(); n != 1 {
+		t.Errorf("expected A2S connection closed once, got %d", n)
+	}
+	if n := logs.count("Game server already disconnected"); n != pollers-1 {
+		t.Errorf("expected %d errors ignored as already disconnected, got %d", pollers-1, n)
+	}
+	if n := dials.Load(); n != 1 {
+		t.Errorf("expected one reconnect loop, got %d connection attempts", n)
+	}
+}
+
+func TestReconnectSingleLoop(t *testing.T) {
+	var dials atomic.Int64
+	c := newConnection("")
+	c.logger = zerolog.Nop()
+	c.lifecycle.logger = zerolog.Nop()
+	c.dial = func() error {
+		dials.Add(1)
+
+		c.mu.Lock()
+		c.connected = true
+		c.mu.Unlock()
+
+		return nil
+	}
+
+	// loop already running, second call must return without connecting
+	c.reconnecting.Store(true)
+	c.reconnect()
+	if n := dials.Load(); n != 0 {
+		t.Errorf("reconnect started while another loop is running, %d connection attempts", n)
+	}
+
+	c.reconnecting.Store(false)
+	c.reconnect()
+	if n := dials.Load(); n != 1 {
+		t.Errorf("expected one connection attempt, got %d", n)
+	}
+	if c.reconnecting.Load() {
+		t.Error("reconnect flag not cleared after loop")
+	}
+}
+
+func TestReconnectLostBeforeFlagCleared(t *testing.T) {
+	var logs syncBuffer
+	c := newConnection("")
+	c.logger = zerolog.New(&logs).Level(zerolog.TraceLevel)
+	c.lifecycle.logger = zerolog.Nop()
+	c.rconCfg.Disabled = true
+	c.queryCfg.Disabled = true
+	c.collector = c.setupCollector(nil)
+
+	var dials atomic.Int64
+	c.dial = func() error {
+		c.mu.Lock()
+		c.rcon = &fakeRcon{}
+		c.query = &fakeQuery{}
+		c.connected = true
+		c.mu.Unlock()
+
+		// first connection fails while reconnect loop is still marked as running,
+		// wait for loop started by error handler to give up
+		if dials.Add(1) == 1 {
+			c.handleError(errors.New("connection lost"), "test")
+
+			deadline := time.Now().Add(2 * time.Second)
+			for logs.count("Reconnect already in progress") == 0 {
+				if time.Now().After(deadline) {
+					t.Error("reconnect is not started by error handler")
+					break
+				}
+				time.Sleep(time.Millisecond)
+			}
+		}
+
+		return nil
+	}
+
+	c.reconnect()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !c.isConnected() || c.reconnecting.Load() {
+		if time.Now().After(deadline) {
+			t.Fatal("connection is not restored")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if n := dials.Load(); n != 2 {
+		t.Errorf("expected 2 connection attempts, got %d", n)
+	}
+}
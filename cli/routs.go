@@ -3,8 +3,10 @@ package main
 import (
 	_ "embed"
 	"encoding/json"
+	"html"
 	"internal/vars"
 	"net/http"
+	"net/url"
 	"strings"
 
 	"github.com/rs/zerolog/log"
@@ -17,18 +19,23 @@ var styleCSS []byte
 
 //go:generate minify style.css -o style.min.css
 
-// check is alive
-func (c *connection) livenessHandler(w http.ResponseWriter, r *http.Request) {
+// check is alive, enabled sources of game servers are checked only if set in config,
+// by default unavailable game server does not restart exporter serving other servers
+func (e *exporter) livenessHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "GET" {
 		log.Debug().Str("method", r.Method).Msg("Method not allowed on liveness")
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
 	}
 
-	if !c.rcon.IsAlive() {
-		log.Warn().Msg("BattleEye RCON not connected")
-		http.Error(w, "BattleEye RCON not connected", http.StatusServiceUnavailable)
-		return
+	if e.liveSources {
+		for _, c := range e.connections {
+			if err := c.alive(); err != nil {
+				c.logger.Warn().Err(err).Msg("Liveness check failed")
+				http.Error(w, err.Error(), http.StatusServiceUnavailable)
+				return
+			}
+		}
 	}
 
 	log.Trace().Msg("Liveness check OK")
@@ -38,14 +45,23 @@ func (c *connection) livenessHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// simple OK if up and ready to handle requests
-func (c *connection) readinessHandler(w http.ResponseWriter, r *http.Request) {
+// OK if each game server was connected at least once and metrics have server labels,
+// later outages do not make exporter not ready, they are reported by dayz_server_up metric
+func (e *exporter) readinessHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "GET" {
 		log.Debug().Str("method", r.Method).Msg("Method not allowed on readiness")
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
 	}
 
+	for _, c := range e.connections {
+		if !c.isReady() {
+			c.logger.Debug().Msg("Game server not connected yet")
+			http.Error(w, "Game server not connected", http.StatusServiceUnavailable)
+			return
+		}
+	}
+
 	log.Trace().Msg("Readiness check OK")
 	w.WriteHeader(http.StatusOK)
 	if _, err := w.Write([]byte("OK")); err != nil {
@@ -53,14 +69,34 @@ func (c *connection) readinessHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// a2s info handler
-func (c *connection) infoHandler(w http.ResponseWriter, r *http.Request) {
+// register /info endpoints, /info/mods takes precedence over server named "mods"
+func (e *exporter) handleInfo(mux *http.ServeMux) {
+	mux.HandleFunc("/info", e.infoHandler)
+	mux.HandleFunc("/info/{server}", e.infoHandler)
+	mux.HandleFunc("/info/mods", e.modsHandler)
+	mux.HandleFunc("/info/{server}/mods", e.modsHandler)
+}
+
+// a2s info handler, serves first configured server or server from path
+func (e *exporter) infoHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "GET" {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
 	}
 
-	if c.info == nil {
+	c := e.connection(r.PathValue("server"))
+	if c == nil {
+		http.Error(w, "Server not found", http.StatusNotFound)
+		return
+	}
+
+	if c.queryCfg.Disabled {
+		http.Error(w, "Server info not available, A2S query disabled", http.StatusNotFound)
+		return
+	}
+
+	info := c.serverInfo()
+	if info == nil {
 		http.Error(w, "Server info not available yet", http.StatusServiceUnavailable)
 		return
 	}
@@ -69,18 +105,49 @@ func (c *connection) infoHandler(w http.ResponseWriter, r *http.Request) {
 		*a2s.Info
 		Keywords keywords.DayZ `json:"keywords"`
 	}{
-		Info:     c.info,
-		Keywords: *keywords.ParseDayZ(c.info.Keywords),
+		Info:     info,
+		Keywords: *keywords.ParseDayZ(info.Keywords),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		log.Error().Err(err).Msg("Failed to encode server info")
+		c.logger.Error().Err(err).Msg("Failed to encode server info")
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
+
+// a2s rules mods handler, serves first configured server or server from path
+func (e *exporter) modsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	c := e.connection(r.PathValue("server"))
+	if c == nil {
+		http.Error(w, "Server not found", http.StatusNotFound)
+		return
+	}
+
+	if c.queryCfg.Disabled || !c.queryCfg.Mods {
+		http.Error(w, "Server mods not available, A2S rules disabled", http.StatusNotFound)
+		return
+	}
+
+	mods := c.serverMods()
+	if mods == nil {
+		http.Error(w, "Server mods not available yet", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(mods); err != nil {
+		c.logger.Error().Err(err).Msg("Failed to encode server mods")
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 	}
 }
 
-func (c *connection) rootHandler(w http.ResponseWriter, r *http.Request) {
+func (e *exporter) rootHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "GET" {
 		log.Debug().Str("method", r.Method).Msg("Method not allowed on index page")
 		w.WriteHeader(http.StatusMethodNotAllowed)
@@ -88,20 +155,59 @@ func (c *connection) rootHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var serverInfo strings.Builder
-	serverInfo.WriteString("server: " + c.info.Name + "\n")
-	if c.info.Game != "" {
-		serverInfo.WriteString("description: " + c.info.Game + "\n")
+	for i, c := range e.connections {
+		if i > 0 {
+			serverInfo.WriteString("\n\n")
+		}
+		if c.name != "" {
+			serverInfo.WriteString(targetLabel + ": " + c.name + "\n")
+		}
+
+		info := c.serverInfo()
+		if c.queryCfg.Disabled {
+			serverInfo.WriteString("server: A2S query disabled")
+			continue
+		}
+		if info == nil {
+			serverInfo.WriteString("server: not connected yet")
+			continue
+		}
+
+		serverInfo.WriteString("server: " + info.Name + "\n")
+		if info.Game != "" {
+			serverInfo.WriteString("description: " + info.Game + "\n")
+		}
+		serverInfo.WriteString(
+			"map: " + info.Map + "\n" +
+				"game: " + info.Folder + "\n" +
+				"os: " + info.Environment.String() + "\n" +
+				"version: " + info.Version,
+		)
+	}
+
+	var infoEndpoint strings.Builder
+	if e.exposeInfo {
+		infoEndpoint.WriteString(`<li><a href="/info">/info</a>: Show A2S_INFO server info in json;</li>`)
+		if e.connections[0].queryCfg.Mods {
+			infoEndpoint.WriteString(`
+			<li><a href="/info/mods">/info/mods</a>: Show server mods from A2S_RULES in json;</li>`)
+		}
+		for _, c := range e.connections {
+			if c.name != "" {
+				path := "/info/" + url.PathEscape(c.name)
+				infoEndpoint.WriteString(`
+			<li><a href="` + path + `">` + html.EscapeString(path) + `</a>: Show A2S_INFO of server ` + html.EscapeString(c.name) + ` in json;</li>`)
+				if c.queryCfg.Mods {
+					infoEndpoint.WriteString(`
+			<li><a href="` + path + `/mods">` + html.EscapeString(path) + `/mods</a>: Show mods of server ` + html.EscapeString(c.name) + ` in json;</li>`)
+				}
+			}
+		}
 	}
-	serverInfo.WriteString(
-		"map: " + c.info.Map + "\n" +
-			"game: " + c.info.Folder + "\n" +
-			"os: " + c.info.Environment.String() + "\n" +
-			"version: " + c.info.Version,
-	)
 
-	infoEndpoint := ""
-	if c.exposeInfo {
-		infoEndpoint = `<li><a href="/info">/info</a>: Show A2S_INFO server info in json;</li>`
+	var liveSources string
+	if e.liveSources {
+		liveSources = " (enabled RCON and A2S connections)"
 	}
 
 	w.Header().Set("Content-Type", "text/html")
@@ -130,15 +236,15 @@ func (c *connection) rootHandler(w http.ResponseWriter, r *http.Request) {
 		<p>This application exposes the following endpoints:</p>
 		<ul>
 			<li><a href="/metrics">/metrics</a>: Exposes Prometheus metrics.</li>
-			` + infoEndpoint + `
+			` + infoEndpoint.String() + `
 			<li><a href="/health">/health</a>: General health check of the service;</li>
-			<li><a href="/health/liveness">/health/liveness</a>: Checks if the service is alive (RCON connection);</li>
-			<li><a href="/health/readiness">/health/readiness</a>: Checks if the service is ready (all required connections are established);</li>
+			<li><a href="/health/liveness">/health/liveness</a>: Checks if the service is alive` + liveSources + `;</li>
+			<li><a href="/health/readiness">/health/readiness</a>: Checks if the service is ready (each game server was connected at least once);</li>
 		</ul>
 		<hr/>
 		<p>Game server information:</p>
 		<pre>
-` + serverInfo.String() + `
+` + html.EscapeString(serverInfo.String()) + `
 		</pre>
 		<p>Exporter information:</p>
 		<pre>
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/woozymasta/a2s/pkg/a2s"
+	"github.com/woozymasta/a2s/pkg/a3sb"
+)
+
+func TestInfoRouting(t *testing.T) {
+	e := &exporter{exposeInfo: true}
+	for _, name := range []string{"main", "pve"} {
+		c := newConnection(name)
+		c.queryCfg = Query{Mods: true}
+		c.info = &a2s.Info{Name: name + " server"}
+		c.mods = []a3sb.Mod{{Name: name + " mod"}}
+		e.connections = append(e.connections, c)
+	}
+
+	mux := http.NewServeMux()
+	e.handleInfo(mux)
+
+	tests := []struct {
+		path, name string
+		status     int
+		mods       bool
+	}{
+		{path: "/info", name: "main server", status: http.StatusOK},
+		{path: "/info/main", name: "main server", status: http.StatusOK},
+		{path: "/info/pve", name: "pve server", status: http.StatusOK},
+		{path: "/info/unknown", status: http.StatusNotFound},
+		{path: "/info/mods", name: "main mod", status: http.StatusOK, mods: true},
+		{path: "/info/pve/mods", name: "pve mod", status: http.StatusOK, mods: true},
+		{path: "/info/unknown/mods", status: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != tt.status {
+				t.Fatalf("expected status %d, got %d", tt.status, rec.Code)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			var name string
+			if tt.mods {
+				var mods []a3sb.Mod
+				if err := json.NewDecoder(rec.Body).Decode(&mods); err != nil || len(mods) != 1 {
+					t.Fatalf("unexpected mods response: %v", err)
+				}
+				name = mods[0].Name
+			} else {
+				var info struct {
+					Name string `json:"name"`
+				}
+				if err := json.NewDecoder(rec.Body).Decode(&info); err != nil {
+					t.Fatal(err)
+				}
+				name = info.Name
+			}
+			if name != tt.name {
+				t.Errorf("expected %q, got %q", tt.name, name)
+			}
+		})
+	}
+}
+
+func TestHealthIgnoresGameServers(t *testing.T) {
+	// one of servers is not connected
+	up, down := newConnection("up"), newConnection("down")
+	up.connected = true
+	e := &exporter{connections: []*connection{up, down}}
+
+	rec := httptest.NewRecorder()
+	e.livenessHandler(rec, httptest.NewRequest(http.MethodGet, "/health/liveness", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+}
+
+func TestLivenessSources(t *testing.T) {
+	tests := []struct {
+		name           string
+		rcon, query    bool // enabled sources
+		rconDead, down bool // RCON connection state, A2S state by last query
+		status         int
+	}{
+		{name: "both up", rcon: true, query: true, status: http.StatusOK},
+		{name: "both rcon dead", rcon: true, query: true, rconDead: true, status: http.StatusServiceUnavailable},
+		{name: "both query down", rcon: true, query: true, down: true, status: http.StatusServiceUnavailable},
+		{name: "rcon only", rcon: true, status: http.StatusOK},
+		{name: "rcon only dead", rcon: true, rconDead: true, status: http.StatusServiceUnavailable},
+		{name: "query only", query: true, status: http.StatusOK},
+		{name: "query only rcon dead", query: true, rconDead: true, status: http.StatusOK},
+		{name: "query only down", query: true, down: true, status: http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newConnection("")
+			c.rconCfg.Disabled = !tt.rcon
+			c.queryCfg.Disabled = !tt.query
+			rcon := &fakeRcon{}
+			rcon.dead.Store(tt.rconDead)
+			if tt.rcon {
+				c.rcon = rcon
+			}
+			if tt.query {
+				c.query = &fakeQuery{}
+			}
+			c.connected = !tt.down
+
+			e := &exporter{connections: []*connection{c}, liveSources: true}
+			rec := httptest.NewRecorder()
+			e.livenessHandler(rec, httptest.NewRequest(http.MethodGet, "/health/liveness", nil))
+			if rec.Code != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, rec.Code)
+			}
+
+			// sources are not checked by default
+			e.liveSources = false
+			rec = httptest.NewRecorder()
+			e.livenessHandler(rec, httptest.NewRequest(http.MethodGet, "/health/liveness", nil))
+			if rec.Code != http.StatusOK {
+				t.Errorf("expected status %d without sources check, got %d", http.StatusOK, rec.Code)
+			}
+		})
+	}
+}
+
+func TestReadiness(t *testing.T) {
+	first, second := newConnection("first"), newConnection("second")
+	e := &exporter{connections: []*connection{first, second}}
+
+	readiness := func() int {
+		rec := httptest.NewRecorder()
+		e.readinessHandler(rec, httptest.NewRequest(http.MethodGet, "/health/readiness", nil))
+		return rec.Code
+	}
+
+	if code := readiness(); code != http.StatusServiceUnavailable {
+		t.Errorf("expected not ready before connections, got status %d", code)
+	}
+
+	first.connected, first.ready = true, true
+	if code := readiness(); code != http.StatusServiceUnavailable {
+		t.Errorf("expected not ready while one of servers is not connected, got status %d", code)
+	}
+
+	second.connected, second.ready = true, true
+	if code := readiness(); code != http.StatusOK {
+		t.Errorf("expected ready after all servers connected, got status %d", code)
+	}
+
+	// later outage is reported by dayz_server_up
+	second.disconnect()
+	if code := readiness(); code != http.StatusOK {
+		t.Errorf("expected ready after server disconnected, got status %d", code)
+	}
+}
@@ -1,6 +1,8 @@
 package bemetrics
 
 import (
+	"strconv"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/woozymasta/a2s/pkg/a2s"
 	"github.com/woozymasta/a2s/pkg/keywords"
@@ -59,12 +61,68 @@ func (mc *MetricsCollector) InitServerMetrics() {
 			labels,
 		)
 	}
+
+	if mc.serverInfo == nil {
+		mc.serverInfo = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "a2s_info_server_info",
+				Help: "Server settings parsed from A2S_INFO keywords, always 1.",
+			},
+			append(labels,
+				"shard", "battleye", "first_person_only", "external", "private_hive",
+				"modded", "whitelist", "file_patching", "dlc",
+			),
+		)
+	}
+
+	if mc.serverDayAccel == nil {
+		mc.serverDayAccel = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "a2s_info_time_acceleration_day",
+				Help: "Day time acceleration on server.",
+			},
+			labels,
+		)
+	}
+
+	if mc.serverNightAccel == nil {
+		mc.serverNightAccel = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "a2s_info_time_acceleration_night",
+				Help: "Night time acceleration on server.",
+			},
+			labels,
+		)
+	}
+
+	if mc.serverPassword == nil {
+		mc.serverPassword = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "a2s_info_password",
+				Help: "Whether the server requires a password (1 for yes, 0 for no).",
+			},
+			labels,
+		)
+	}
+
+	if mc.serverVAC == nil {
+		mc.serverVAC = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "a2s_info_vac",
+				Help: "Whether the server is protected by VAC (1 for yes, 0 for no).",
+			},
+			labels,
+		)
+	}
 }
 
 // UpdateServerMetrics use for update a2s server metrics
 func (mc *MetricsCollector) UpdateServerMetrics(serverInfo *a2s.Info) {
 	extendedInfo := keywords.ParseDayZ(serverInfo.Keywords)
 
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+
 	values := mc.customLabels.Values()
 
 	if mc.serverPing != nil {
@@ -86,4 +144,45 @@ func (mc *MetricsCollector) UpdateServerMetrics(serverInfo *a2s.Info) {
 	if mc.serverTime != nil {
 		mc.serverTime.WithLabelValues(values...).Set(float64(extendedInfo.Time))
 	}
+
+	if mc.serverInfo != nil {
+		mc.serverInfo.Reset() // settings can be changed on server restart, reset it always
+
+		infoLabels := append(values,
+			extendedInfo.Shard,
+			strconv.FormatBool(extendedInfo.BattlEye),
+			strconv.FormatBool(extendedInfo.NoThirdPerson),
+			strconv.FormatBool(extendedInfo.External),
+			strconv.FormatBool(extendedInfo.PrivateHive),
+			strconv.FormatBool(extendedInfo.Modded),
+			strconv.FormatBool(extendedInfo.Whitelist),
+			strconv.FormatBool(extendedInfo.FlePatching),
+			strconv.FormatBool(extendedInfo.DLC),
+		)
+		mc.serverInfo.WithLabelValues(infoLabels...).Set(1)
+	}
+
+	if mc.serverDayAccel != nil {
+		mc.serverDayAccel.WithLabelValues(values...).Set(extendedInfo.TimeDayAccel)
+	}
+
+	if mc.serverNightAccel != nil {
+		mc.serverNightAccel.WithLabelValues(values...).Set(extendedInfo.TimeNightAccel)
+	}
+
+	if mc.serverPassword != nil {
+		mc.serverPassword.WithLabelValues(values...).Set(boolToFloat(serverInfo.Visibility))
+	}
+
+	if mc.serverVAC != nil {
+		mc.serverVAC.WithLabelValues(values...).Set(boolToFloat(serverInfo.VAC))
+	}
+}
+
+// convert bool to metric value
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
 }
@@ -0,0 +1,106 @@
+package bemetrics
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/woozymasta/a2s/pkg/a2s"
+)
+
+// buckets of players session duration histogram in seconds, from 1 minute to 12 hours
+var sessionBuckets = []float64{60, 300, 900, 1800, 3600, 7200, 14400, 28800, 43200}
+
+// InitSessionMetrics initialize a2s players session metrics
+func (mc *MetricsCollector) InitSessionMetrics() {
+	labels := mc.customLabels.Keys()
+
+	if mc.sessionDuration == nil {
+		mc.sessionDuration = prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "a2s_players_session_seconds",
+				Help:    "Session duration of players currently on server in seconds.",
+				Buckets: sessionBuckets,
+			},
+			labels,
+		)
+	}
+
+	if mc.sessionPlayers == nil {
+		mc.sessionPlayers = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "a2s_players",
+				Help: "Count of players in A2S_PLAYER response.",
+			},
+			labels,
+		)
+	}
+}
+
+// InitPlayerSessionMetrics initialize a2s per-player session and score metrics
+func (mc *MetricsCollector) InitPlayerSessionMetrics() {
+	labels := mc.customLabels.Keys()
+
+	if mc.playerSession == nil {
+		mc.playerSession = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "a2s_player_session_seconds",
+				Help: "Session duration of player in seconds.",
+			},
+			append(labels, "name", "index"),
+		)
+	}
+
+	if mc.playerScore == nil {
+		mc.playerScore = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "a2s_player_score",
+				Help: "Score of player.",
+			},
+			append(labels, "name", "index"),
+		)
+	}
+}
+
+// UpdateSessionMetrics use for update a2s players session metrics
+func (mc *MetricsCollector) UpdateSessionMetrics(players *[]a2s.Player) {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+
+	values := mc.customLabels.Values()
+
+	if mc.sessionDuration != nil {
+		// histogram represent only current sessions, reset it always
+		mc.sessionDuration.Reset()
+		observer := mc.sessionDuration.WithLabelValues(values...)
+
+		for _, player := range *players {
+			observer.Observe(player.Duration.Seconds())
+		}
+	}
+
+	if mc.sessionPlayers != nil {
+		mc.sessionPlayers.WithLabelValues(values...).Set(float64(len(*players)))
+	}
+
+	if mc.playerSession != nil {
+		mc.playerSession.Reset() // count of metrics is dynamic, reset it always
+	}
+	if mc.playerScore != nil {
+		mc.playerScore.Reset()
+	}
+
+	// players with dropped names may share series, it shows their average session and score
+	sessions, scores := mergedSeries{}, mergedSeries{}
+	for _, player := range *players {
+		playerLabels := append(values, mc.privacy.MaskName(player.Name), strconv.Itoa(int(player.Index)))
+		sessions.add(playerLabels, player.Duration.Seconds())
+		scores.add(playerLabels, float64(player.Score))
+	}
+
+	if mc.playerSession != nil {
+		sessions.set(mc.playerSession, average)
+	}
+	if mc.playerScore != nil {
+		scores.set(mc.playerScore, average)
+	}
+}
@@ -0,0 +1,54 @@
+package bemetrics
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/woozymasta/a2s/pkg/a3sb"
+)
+
+// InitModsMetrics initialize a2s rules server mods metrics
+func (mc *MetricsCollector) InitModsMetrics() {
+	labels := mc.customLabels.Keys()
+
+	if mc.modInfo == nil {
+		mc.modInfo = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "dayz_server_mod_info",
+				Help: "Mod installed on server, always 1.",
+			},
+			append(labels, "id", "name"),
+		)
+	}
+
+	if mc.modsTotal == nil {
+		mc.modsTotal = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "dayz_server_mods",
+				Help: "Count of mods installed on server.",
+			},
+			labels,
+		)
+	}
+}
+
+// UpdateModsMetrics use for update a2s rules server mods metrics
+func (mc *MetricsCollector) UpdateModsMetrics(rules *a3sb.Rules) {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+
+	values := mc.customLabels.Values()
+
+	if mc.modInfo != nil {
+		mc.modInfo.Reset() // mods can be changed on server restart, reset it always
+
+		for _, mod := range rules.Mods {
+			modLabels := append(values, strconv.FormatUint(mod.ID, 10), mod.Name)
+			mc.modInfo.WithLabelValues(modLabels...).Set(1)
+		}
+	}
+
+	if mc.modsTotal != nil {
+		mc.modsTotal.WithLabelValues(values...).Set(float64(len(rules.Mods)))
+	}
+}
@@ -1,10 +1,45 @@
 package bemetrics
 
 import (
+	"cmp"
+	"slices"
+	"sync"
+	"time"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/woozymasta/bercon-cli/pkg/beparser"
 )
 
+// default window for count bans expiring soon
+const defaultBansExpiringWindow = 24 * time.Hour
+
+// BanAction is a kind of ban list change
+type BanAction string
+
+// ban list changes
+const (
+	BanAdded   BanAction = "added"   // ban appeared in ban list
+	BanRemoved BanAction = "removed" // ban lifted or expired
+)
+
+// BanChange is a difference between two ban lists, ban is identified by type and GUID or IP
+type BanChange struct {
+	Action      BanAction `json:"action"`
+	Type        string    `json:"type"`              // ban type "guid" or "ip"
+	Value       string    `json:"value"`             // banned GUID or IP
+	Reason      string    `json:"reason,omitempty"`  // ban reason
+	Country     string    `json:"country,omitempty"` // country of banned IP
+	MinutesLeft int       `json:"minutes"`           // minutes left, -1 for permanent ban
+}
+
+// bansState is a ban list snapshot used for find changes
+type bansState struct {
+	bans           map[string]BanChange // bans by type and GUID or IP
+	expiringWindow time.Duration        // window for count bans expiring soon
+	mu             sync.Mutex           // guards snapshot, updates hold only read lock of collector
+	seen           bool                 // flag for first snapshot used as baseline
+}
+
 // InitBansMetrics initialize bercon ban metrics
 func (mc *MetricsCollector) InitBansMetrics() {
 	labels := mc.customLabels.Keys()
@@ -19,23 +54,30 @@ func (mc *MetricsCollector) InitBansMetrics() {
 		)
 	}
 
-	if mc.banGUIDTotal == nil {
-		mc.banGUIDTotal = prometheus.NewGaugeVec(
+	if mc.banIPTimeMetric == nil {
+		mc.banIPTimeMetric = prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
-				Name: "bercon_ban_guid_total",
-				Help: "Total count of GUID bans.",
+				Name: "bercon_ban_ip_time_seconds",
+				Help: "Time left for IP bans in seconds.",
 			},
-			labels,
+			append(labels, "reason", "ip", "country", "asn", "as_org"),
 		)
 	}
 
-	if mc.banIPTimeMetric == nil {
-		mc.banIPTimeMetric = prometheus.NewGaugeVec(
+	mc.initBansSummaryMetrics()
+}
+
+// initialize bercon bans totals and ban list changes metrics
+func (mc *MetricsCollector) initBansSummaryMetrics() {
+	labels := mc.customLabels.Keys()
+
+	if mc.banGUIDTotal == nil {
+		mc.banGUIDTotal = prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
-				Name: "bercon_ban_ip_time_seconds",
-				Help: "Time left for IP bans in seconds.",
+				Name: "bercon_ban_guid_total",
+				Help: "Total count of GUID bans.",
 			},
-			append(labels, "reason", "ip", "country"),
+			labels,
 		)
 	}
 
@@ -48,19 +90,70 @@ func (mc *MetricsCollector) InitBansMetrics() {
 			labels,
 		)
 	}
+
+	if mc.bansState == nil {
+		mc.bansState = &bansState{expiringWindow: defaultBansExpiringWindow}
+	}
+
+	if mc.bansAdded == nil {
+		mc.bansAdded = prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "bercon_bans_added_total",
+				Help: "Total count of bans added to ban list.",
+			},
+			append(labels, "type"),
+		)
+	}
+
+	if mc.bansRemoved == nil {
+		mc.bansRemoved = prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "bercon_bans_removed_total",
+				Help: "Total count of bans removed from ban list, lifted or expired.",
+			},
+			append(labels, "type"),
+		)
+	}
+
+	if mc.bansExpiring == nil {
+		mc.bansExpiring = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "bercon_bans_expiring",
+				Help: "Count of temporary bans expiring within configured window.",
+			},
+			append(labels, "type"),
+		)
+	}
 }
 
-// UpdateBansMetrics use for update ban metrics (GUID and IP)
-func (mc *MetricsCollector) UpdateBansMetrics(bans *beparser.Bans) {
+// SetBansExpiringWindow use for set window for count bans expiring soon, 24 hours by default
+func (mc *MetricsCollector) SetBansExpiringWindow(window time.Duration) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	if mc.bansState != nil && window > 0 {
+		mc.bansState.expiringWindow = window
+	}
+}
+
+// UpdateBansMetrics use for update ban metrics (GUID and IP),
+// returns changes from previous ban list, first update is used as baseline without changes
+func (mc *MetricsCollector) UpdateBansMetrics(bans *beparser.Bans) []BanChange {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+
 	values := mc.customLabels.Values()
 
 	// update GUID bans
 	if mc.banGUIDTimeMetric != nil {
 		mc.banGUIDTimeMetric.Reset() // count of metrics is dynamic, reset it always
 
+		// bans with masked GUID may share series, it shows the longest time left
+		series := mergedSeries{}
 		for _, ban := range bans.GUIDBans {
-			mc.banGUIDTimeMetric.WithLabelValues(append(values, ban.Reason, ban.GUID)...).Set(banSeconds(ban.MinutesLeft))
+			series.add(append(values, ban.Reason, mc.privacy.MaskGUID(ban.GUID)), banSeconds(ban.MinutesLeft))
 		}
+		series.set(mc.banGUIDTimeMetric, longestBan)
 	}
 
 	if mc.banGUIDTotal != nil {
@@ -68,17 +161,98 @@ func (mc *MetricsCollector) UpdateBansMetrics(bans *beparser.Bans) {
 	}
 
 	// update IP bans
+	locations := mc.lookupBans(bans.IPBans)
 	if mc.banIPTimeMetric != nil {
 		mc.banIPTimeMetric.Reset() // count of metrics is dynamic, reset it always
 
-		for _, ban := range bans.IPBans {
-			mc.banIPTimeMetric.WithLabelValues(append(values, ban.Reason, ban.IP, ban.Country)...).Set(banSeconds(ban.MinutesLeft))
+		// bans with masked IP may share series, it shows the longest time left
+		series := mergedSeries{}
+		for i, ban := range bans.IPBans {
+			banLabels := append(values, ban.Reason, mc.privacy.MaskIP(ban.IP), ban.Country, locations[i].asnLabel(), locations[i].ASOrg)
+			series.add(banLabels, banSeconds(ban.MinutesLeft))
 		}
+		series.set(mc.banIPTimeMetric, longestBan)
 	}
 
 	if mc.banIPTotal != nil {
 		mc.banIPTotal.WithLabelValues(values...).Set(float64(len(bans.IPBans)))
 	}
+
+	mc.updateBansAggregate(bans, values)
+	geoMetrics{byCountry: mc.bansByCountry, byContinent: mc.bansByContinent, byASN: mc.bansByASN}.update(locations, values)
+
+	if mc.bansState == nil {
+		return nil
+	}
+
+	current := make(map[string]BanChange, len(bans.GUIDBans)+len(bans.IPBans))
+	for _, ban := range bans.GUIDBans {
+		current["guid/"+ban.GUID] = BanChange{Type: "guid", Value: mc.privacy.MaskGUID(ban.GUID), Reason: ban.Reason, MinutesLeft: ban.MinutesLeft}
+	}
+	for _, ban := range bans.IPBans {
+		current["ip/"+ban.IP] = BanChange{Type: "ip", Value: mc.privacy.MaskIP(ban.IP), Reason: ban.Reason, Country: ban.Country, MinutesLeft: ban.MinutesLeft}
+	}
+
+	changes := mc.bansState.diff(current)
+	for _, change := range changes {
+		switch change.Action {
+		case BanAdded:
+			if mc.bansAdded != nil {
+				mc.bansAdded.WithLabelValues(append(values, change.Type)...).Inc()
+			}
+		case BanRemoved:
+			if mc.bansRemoved != nil {
+				mc.bansRemoved.WithLabelValues(append(values, change.Type)...).Inc()
+			}
+		}
+	}
+
+	if mc.bansExpiring != nil {
+		window := int(mc.bansState.expiringWindow.Minutes())
+		expiring := map[string]float64{"guid": 0, "ip": 0}
+		for _, ban := range current {
+			if ban.MinutesLeft > 0 && ban.MinutesLeft <= window {
+				expiring[ban.Type]++
+			}
+		}
+		for banType, count := range expiring {
+			mc.bansExpiring.WithLabelValues(append(values, banType)...).Set(count)
+		}
+	}
+
+	return changes
+}
+
+// replace ban list snapshot and return added and removed bans
+func (s *bansState) diff(current map[string]BanChange) []BanChange {
+	s.mu.Lock()
+	previous, seen := s.bans, s.seen
+	s.bans, s.seen = current, true
+	s.mu.Unlock()
+
+	if !seen {
+		return nil
+	}
+
+	var changes []BanChange
+	for key, ban := range current {
+		if _, ok := previous[key]; !ok {
+			ban.Action = BanAdded
+			changes = append(changes, ban)
+		}
+	}
+	for key, ban := range previous {
+		if _, ok := current[key]; !ok {
+			ban.Action = BanRemoved
+			changes = append(changes, ban)
+		}
+	}
+
+	slices.SortFunc(changes, func(a, b BanChange) int {
+		return cmp.Or(cmp.Compare(a.Action, b.Action), cmp.Compare(a.Type, b.Type), cmp.Compare(a.Value, b.Value))
+	})
+
+	return changes
 }
 
 func banSeconds(minutes int) float64 {
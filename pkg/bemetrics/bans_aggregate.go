@@ -0,0 +1,151 @@
+package bemetrics
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/woozymasta/bercon-cli/pkg/beparser"
+)
+
+// default count of most common ban reasons exposed as is, other reasons are grouped as "other"
+const defaultBanReasonsLimit = 20
+
+// buckets of ban time left histogram in seconds, from 1 hour to 1 year
+var banTimeBuckets = []float64{3600, 21600, 86400, 604800, 2592000, 7776000, 31536000}
+
+// InitBansAggregateMetrics initialize low cardinality bercon ban metrics,
+// alternative to InitBansMetrics without series for each ban
+func (mc *MetricsCollector) InitBansAggregateMetrics() {
+	labels := mc.customLabels.Keys()
+
+	if mc.banReasonsLimit == 0 {
+		mc.banReasonsLimit = defaultBanReasonsLimit
+	}
+
+	if mc.bansByTerm == nil {
+		mc.bansByTerm = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "bercon_bans",
+				Help: "Count of bans by type and term, permanent or temporary.",
+			},
+			append(labels, "type", "term"),
+		)
+	}
+
+	if mc.banTimeLeft == nil {
+		mc.banTimeLeft = prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "bercon_ban_time_left_seconds",
+				Help:    "Time left for temporary bans in seconds.",
+				Buckets: banTimeBuckets,
+			},
+			append(labels, "type"),
+		)
+	}
+
+	if mc.bansByReason == nil {
+		mc.bansByReason = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "bercon_bans_by_reason",
+				Help: "Count of bans by normalized reason, less common reasons are grouped as other.",
+			},
+			append(labels, "type", "reason"),
+		)
+	}
+
+	mc.initBansSummaryMetrics()
+}
+
+// SetBanReasonsLimit use for set count of most common ban reasons exposed as is, 20 by default
+func (mc *MetricsCollector) SetBanReasonsLimit(limit int) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	if limit > 0 {
+		mc.banReasonsLimit = limit
+	}
+}
+
+// NormalizeBanReason returns ban reason in lower case without variable details suitable for metric label
+func NormalizeBanReason(reason string) string {
+	reason = kickDetailsRegexp.ReplaceAllString(reason, "")
+	reason = strings.Trim(strings.ToLower(reason), " -:.,/")
+	if reason == "" {
+		return "unknown"
+	}
+
+	return reason
+}
+
+// update low cardinality ban metrics
+func (mc *MetricsCollector) updateBansAggregate(bans *beparser.Bans, values []string) {
+	if mc.bansByTerm == nil && mc.banTimeLeft == nil && mc.bansByReason == nil {
+		return
+	}
+
+	type reasonKey struct{ banType, reason string }
+	reasons := make(map[reasonKey]float64)
+	terms := map[[2]string]float64{
+		{"guid", "permanent"}: 0, {"guid", "temporary"}: 0,
+		{"ip", "permanent"}: 0, {"ip", "temporary"}: 0,
+	}
+
+	if mc.banTimeLeft != nil {
+		mc.banTimeLeft.Reset() // histogram represent only current ban list, reset it always
+	}
+
+	observe := func(banType, reason string, minutesLeft int) {
+		term := "permanent"
+		if minutesLeft > 0 {
+			term = "temporary"
+			if mc.banTimeLeft != nil {
+				mc.banTimeLeft.WithLabelValues(append(values, banType)...).Observe(banSeconds(minutesLeft))
+			}
+		}
+		terms[[2]string{banType, term}]++
+		reasons[reasonKey{banType, NormalizeBanReason(reason)}]++
+	}
+
+	for _, ban := range bans.GUIDBans {
+		observe("guid", ban.Reason, ban.MinutesLeft)
+	}
+	for _, ban := range bans.IPBans {
+		observe("ip", ban.Reason, ban.MinutesLeft)
+	}
+
+	if mc.bansByTerm != nil {
+		for key, count := range terms {
+			mc.bansByTerm.WithLabelValues(append(values, key[0], key[1])...).Set(count)
+		}
+	}
+
+	if mc.bansByReason != nil {
+		// keep most common reasons, group others to limit cardinality
+		totals := make(map[string]float64)
+		for key, count := range reasons {
+			totals[key.reason] += count
+		}
+		top := slices.SortedFunc(maps.Keys(totals), func(a, b string) int {
+			return cmp.Or(cmp.Compare(totals[b], totals[a]), cmp.Compare(a, b))
+		})
+		if len(top) > mc.banReasonsLimit {
+			top = top[:mc.banReasonsLimit]
+		}
+
+		grouped := make(map[reasonKey]float64)
+		for key, count := range reasons {
+			if !slices.Contains(top, key.reason) {
+				key.reason = "other"
+			}
+			grouped[key] += count
+		}
+
+		mc.bansByReason.Reset() // count of metrics is dynamic, reset it always
+		for key, count := range grouped {
+			mc.bansByReason.WithLabelValues(append(values, key.banType, key.reason)...).Set(count)
+		}
+	}
+}
@@ -1,12 +1,18 @@
 package bemetrics
 
 import (
+	"slices"
+	"sync"
+
 	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 )
 
 // MetricsCollector represent a responsible for storing various metrics
 type MetricsCollector struct {
 	playerPingMetric    *prometheus.GaugeVec
+	playersPing         *prometheus.HistogramVec
+	countryPing         *prometheus.GaugeVec
 	playersTotal        *prometheus.GaugeVec
 	playersOnline       *prometheus.GaugeVec
 	playersLobby        *prometheus.GaugeVec
@@ -20,13 +26,58 @@ type MetricsCollector struct {
 	serverPlayersSlots  *prometheus.GaugeVec
 	serverPlayersQueue  *prometheus.GaugeVec
 	serverTime          *prometheus.GaugeVec
+	serverInfo          *prometheus.GaugeVec
+	serverDayAccel      *prometheus.GaugeVec
+	serverNightAccel    *prometheus.GaugeVec
+	serverPassword      *prometheus.GaugeVec
+	serverVAC           *prometheus.GaugeVec
+	sessionDuration     *prometheus.HistogramVec
+	sessionPlayers      *prometheus.GaugeVec
+	playerSession       *prometheus.GaugeVec
+	playerScore         *prometheus.GaugeVec
+	modInfo             *prometheus.GaugeVec
+	modsTotal           *prometheus.GaugeVec
+	playerJoins         *prometheus.CounterVec
+	playerLeaves        *prometheus.CounterVec
+	playerSessions      *prometheus.HistogramVec
+	playersUnique       *prometheus.GaugeVec
+	eventConnects       *prometheus.CounterVec
+	eventDisconnects    *prometheus.CounterVec
+	eventKicks          *prometheus.CounterVec
+	eventChat           *prometheus.CounterVec
+	eventAdminLogins    *prometheus.CounterVec
+	bansAdded           *prometheus.CounterVec
+	bansRemoved         *prometheus.CounterVec
+	bansExpiring        *prometheus.GaugeVec
+	bansByTerm          *prometheus.GaugeVec
+	banTimeLeft         *prometheus.HistogramVec
+	bansByCountry       *prometheus.GaugeVec
+	bansByContinent     *prometheus.GaugeVec
+	playersByCountry    *prometheus.GaugeVec
+	playersByContinent  *prometheus.GaugeVec
+	playersByASN        *prometheus.GaugeVec
+	bansByASN           *prometheus.GaugeVec
+	playersByLocation   *prometheus.GaugeVec
+	bansByReason        *prometheus.GaugeVec
+	tracker             *playerTracker
+	bansState           *bansState
+	kickReasons         []KickReason
+	kickReasonOther     string
+	banReasonsLimit     int
+	privacy             Privacy
+	pingNative          bool
+	schema              Schema
+	geoLookup           GeoLookup
+	locationPrecision   int
 	customLabels        Labels
+	mu                  sync.RWMutex // guards labels, updates hold it to not create series with stale labels
 }
 
 // NewMetricsCollector creates an empty MetricsCollector instance
 func NewMetricsCollector(customLabels Labels) *MetricsCollector {
 	return &MetricsCollector{
-		customLabels: customLabels,
+		customLabels:      customLabels,
+		locationPrecision: defaultLocationPrecision,
 	}
 }
 
@@ -35,6 +86,8 @@ func (mc *MetricsCollector) getAllMetrics() []prometheus.Collector {
 	return []prometheus.Collector{
 		// players
 		mc.playerPingMetric,
+		mc.playersPing,
+		mc.countryPing,
 		mc.playersTotal,
 		mc.playersOnline,
 		mc.playersInvalid,
@@ -44,33 +97,184 @@ func (mc *MetricsCollector) getAllMetrics() []prometheus.Collector {
 		mc.banGUIDTotal,
 		mc.banIPTimeMetric,
 		mc.banIPTotal,
+		mc.bansAdded,
+		mc.bansRemoved,
+		mc.bansExpiring,
+		mc.bansByTerm,
+		mc.banTimeLeft,
+		mc.bansByReason,
+		// geo
+		mc.playersByCountry,
+		mc.playersByContinent,
+		mc.bansByCountry,
+		mc.bansByContinent,
+		mc.playersByASN,
+		mc.bansByASN,
+		mc.playersByLocation,
 		// server
 		mc.serverPing,
 		mc.serverPlayersOnline,
 		mc.serverPlayersSlots,
 		mc.serverPlayersQueue,
 		mc.serverTime,
+		mc.serverInfo,
+		mc.serverDayAccel,
+		mc.serverNightAccel,
+		mc.serverPassword,
+		mc.serverVAC,
+		// sessions
+		mc.sessionDuration,
+		mc.sessionPlayers,
+		mc.playerSession,
+		mc.playerScore,
+		// mods
+		mc.modInfo,
+		mc.modsTotal,
+		// players tracker
+		mc.playerJoins,
+		mc.playerLeaves,
+		mc.playerSessions,
+		mc.playersUnique,
+		// server messages
+		mc.eventConnects,
+		mc.eventDisconnects,
+		mc.eventKicks,
+		mc.eventChat,
+		mc.eventAdminLogins,
 	}
 }
 
-// RegisterMetrics use for register only initialized metrics
+// RegisterMetrics use for register only initialized metrics in default prometheus registry
 func (mc *MetricsCollector) RegisterMetrics() {
+	mc.RegisterMetricsWith(prometheus.DefaultRegisterer)
+}
+
+// RegisterMetricsWith use for register only initialized metrics in provided registry,
+// metrics are registered with names of schema set by SetSchema
+func (mc *MetricsCollector) RegisterMetricsWith(reg prometheus.Registerer) {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+
+	reg.MustRegister(mc.schemaMetrics()...)
+}
+
+// SetLabels use for replace values of custom labels, gauges are reset if values changed,
+// so stale series are removed and created again on next update. Counters are moved to
+// new labels values with collected totals, cumulative histograms start from empty.
+// Returns true if labels changed, labels keys must match with keys used on initialization.
+func (mc *MetricsCollector) SetLabels(labels Labels) bool {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	if slices.Equal(mc.customLabels, labels) {
+		return false
+	}
+
+	previous := mc.customLabels
+	mc.customLabels = labels
+	mc.resetMetrics()
+	mc.moveCounters(previous, labels)
+
+	return true
+}
+
+// Labels returns current custom labels
+func (mc *MetricsCollector) Labels() Labels {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+
+	return slices.Clone(mc.customLabels)
+}
+
+// ResetMetrics use for resets initialized gauges and histograms of current state,
+// counters and cumulative histograms are kept to not break rate() over reconnects
+func (mc *MetricsCollector) ResetMetrics() {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.resetMetrics()
+}
+
+// resets initialized gauges and histograms of current state
+func (mc *MetricsCollector) resetMetrics() {
+	cumulative := mc.cumulativeHistograms()
 	for _, metric := range mc.getAllMetrics() {
-		if gaugeVec, ok := metric.(*prometheus.GaugeVec); ok {
-			if gaugeVec != nil {
-				prometheus.MustRegister(gaugeVec)
+		switch vec := metric.(type) {
+		case *prometheus.GaugeVec:
+			if vec != nil {
+				vec.Reset()
+			}
+		case *prometheus.HistogramVec:
+			if vec != nil && !slices.Contains(cumulative, vec) {
+				vec.Reset()
 			}
 		}
 	}
+
+	// players may leave while metrics are not updated, start tracking from new baseline
+	if mc.tracker != nil {
+		mc.tracker.reset()
+	}
 }
 
-// ResetMetrics use for resets all initialized metrics
-func (mc *MetricsCollector) ResetMetrics() {
+// returns histograms with observations accumulated over time, other histograms represent current state
+func (mc *MetricsCollector) cumulativeHistograms() []*prometheus.HistogramVec {
+	return []*prometheus.HistogramVec{
+		mc.playersPing,
+		mc.playerSessions,
+	}
+}
+
+// move counters series from previous custom labels values to current with collected totals,
+// cumulative histograms can't be moved, their series with previous labels are removed
+func (mc *MetricsCollector) moveCounters(previous, current Labels) {
+	match := make(prometheus.Labels, len(previous))
+	for _, label := range previous {
+		match[label.Key] = label.Value
+	}
+
 	for _, metric := range mc.getAllMetrics() {
-		if gaugeVec, ok := metric.(*prometheus.GaugeVec); ok {
-			if gaugeVec != nil {
-				gaugeVec.Reset()
+		vec, ok := metric.(*prometheus.CounterVec)
+		if !ok || vec == nil {
+			continue
+		}
+
+		type series struct {
+			labels prometheus.Labels
+			value  float64
+		}
+		var moved []series
+
+		ch := make(chan prometheus.Metric)
+		go func() {
+			vec.Collect(ch)
+			close(ch)
+		}()
+		for m := range ch {
+			var d dto.Metric
+			if err := m.Write(&d); err != nil || d.GetCounter() == nil {
+				continue
+			}
+
+			labels := make(prometheus.Labels, len(d.GetLabel()))
+			for _, pair := range d.GetLabel() {
+				labels[pair.GetName()] = pair.GetValue()
+			}
+			for _, label := range current {
+				labels[label.Key] = label.Value
 			}
+			moved = append(moved, series{labels: labels, value: d.GetCounter().GetValue()})
+		}
+
+		vec.DeletePartialMatch(match)
+		for _, s := range moved {
+			vec.With(s.labels).Add(s.value)
+		}
+	}
+
+	for _, vec := range mc.cumulativeHistograms() {
+		if vec != nil {
+			vec.DeletePartialMatch(match)
 		}
 	}
 }
@@ -4,10 +4,14 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/oschwald/geoip2-golang"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/woozymasta/a2s/pkg/a2s"
 	"github.com/woozymasta/bercon-cli/pkg/beparser"
 	"github.com/woozymasta/bercon-cli/pkg/bercon"
@@ -78,6 +82,8 @@ func TestPlayerMetricsFromFile(t *testing.T) {
 	// initialize and register metrics for players
 	mc := NewMetricsCollector(getCustomLabels())
 	mc.InitPlayerMetrics()
+	mc.InitPlayerPingMetrics()
+	mc.InitCountryPingMetrics()
 	mc.RegisterMetrics()
 
 	// update metrics with player data
@@ -210,3 +216,377 @@ func TestBansMetricsFromBerconSeparateRegistry(t *testing.T) {
 		}
 	}
 }
+
+func TestSessionMetrics(t *testing.T) {
+	mc := NewMetricsCollector(getCustomLabels())
+	mc.InitSessionMetrics()
+	mc.InitPlayerSessionMetrics()
+
+	registry := prometheus.NewRegistry()
+	mc.RegisterMetricsWith(registry)
+
+	mc.UpdateSessionMetrics(&[]a2s.Player{
+		{Name: "Survivor", Duration: 2 * time.Hour, Score: 5},
+		{Name: "Survivor", Duration: 30 * time.Second, Index: 1},
+	})
+	mc.UpdateSessionMetrics(&[]a2s.Player{
+		{Name: "Survivor", Duration: 2*time.Hour + time.Minute, Score: 5},
+	})
+
+	// histogram and per-player series represent only last response
+	if n := testutil.CollectAndCount(mc.playerSession); n != 1 {
+		t.Errorf("expected 1 player session series, got %d", n)
+	}
+	if v := testutil.ToFloat64(mc.sessionPlayers); v != 1 {
+		t.Errorf("expected 1 player, got %v", v)
+	}
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather metrics: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() != "a2s_players_session_seconds" {
+			continue
+		}
+		if count := family.GetMetric()[0].GetHistogram().GetSampleCount(); count != 1 {
+			t.Errorf("expected 1 session in histogram, got %d", count)
+		}
+		return
+	}
+	t.Error("a2s_players_session_seconds not registered")
+}
+
+func TestServerInfoMetrics(t *testing.T) {
+	mc := NewMetricsCollector(getCustomLabels())
+	mc.InitServerMetrics()
+
+	mc.UpdateServerMetrics(&a2s.Info{
+		Keywords: []string{"battleye", "external", "privHive", "shard001", "lqs0", "etm4.000000", "entm8.000000", "12:00"},
+		VAC:      true,
+	})
+	// server switched to third person view and BattlEye disabled
+	mc.UpdateServerMetrics(&a2s.Info{
+		Keywords:   []string{"no3rd", "external", "privHive", "shard001", "lqs0", "etm4.000000", "entm8.000000", "12:00"},
+		Visibility: true,
+	})
+
+	// only settings from last response are exposed
+	if n := testutil.CollectAndCount(mc.serverInfo); n != 1 {
+		t.Fatalf("expected 1 server info series, got %d", n)
+	}
+	info := mc.serverInfo.WithLabelValues("111", "222", "001", "false", "true", "true", "true", "false", "false", "false", "false")
+	if v := testutil.ToFloat64(info); v != 1 {
+		t.Errorf("expected server info series with updated settings, got %v", v)
+	}
+
+	if v := testutil.ToFloat64(mc.serverDayAccel); v != 4 {
+		t.Errorf("expected day time acceleration 4, got %v", v)
+	}
+	if v := testutil.ToFloat64(mc.serverNightAccel); v != 8 {
+		t.Errorf("expected night time acceleration 8, got %v", v)
+	}
+	if v := testutil.ToFloat64(mc.serverPassword); v != 1 {
+		t.Errorf("expected password flag 1, got %v", v)
+	}
+	if v := testutil.ToFloat64(mc.serverVAC); v != 0 {
+		t.Errorf("expected VAC flag 0, got %v", v)
+	}
+}
+
+func TestSetLabels(t *testing.T) {
+	mc := NewMetricsCollector(getCustomLabels())
+	mc.InitServerMetrics()
+	mc.UpdateServerMetrics(&a2s.Info{Players: 10})
+
+	if mc.SetLabels(getCustomLabels()) {
+		t.Error("expected no labels change for same labels")
+	}
+
+	updated := Labels{{Key: "AAA", Value: "111"}, {Key: "BBB", Value: "333"}}
+	if !mc.SetLabels(updated) {
+		t.Fatal("expected labels change")
+	}
+	if n := testutil.CollectAndCount(mc.serverPlayersOnline); n != 0 {
+		t.Errorf("expected stale series removed, got %d series", n)
+	}
+
+	mc.UpdateServerMetrics(&a2s.Info{Players: 12})
+	if v := testutil.ToFloat64(mc.serverPlayersOnline.WithLabelValues("111", "333")); v != 12 {
+		t.Errorf("expected 12 players with new labels, got %v", v)
+	}
+}
+
+func TestResetKeepsCounters(t *testing.T) {
+	mc := NewMetricsCollector(getCustomLabels())
+	mc.InitServerMetrics()
+	mc.InitPlayerMetrics()
+	mc.InitEventMetrics()
+	mc.UpdateServerMetrics(&a2s.Info{Players: 10})
+	mc.UpdatePlayerMetrics(&beparser.Players{{Ping: 50, Valid: true}})
+	mc.HandleEvent(ParseEvent([]byte("Player #1 A (-) has been kicked by BattlEye: Global Ban #1a2b3c")))
+
+	mc.ResetMetrics()
+	if n := testutil.CollectAndCount(mc.serverPlayersOnline); n != 0 {
+		t.Errorf("expected gauges reset, got %d series", n)
+	}
+	if v := testutil.ToFloat64(mc.eventKicks.WithLabelValues("111", "222", "Global Ban")); v != 1 {
+		t.Errorf("expected kicks counter kept, got %v", v)
+	}
+	if n := testutil.CollectAndCount(mc.playersPing); n != 1 {
+		t.Errorf("expected ping histogram kept, got %d series", n)
+	}
+}
+
+func TestSetLabelsMovesCounters(t *testing.T) {
+	mc := NewMetricsCollector(getCustomLabels())
+	mc.InitPlayerMetrics()
+	mc.InitEventMetrics()
+	mc.UpdatePlayerMetrics(&beparser.Players{{Ping: 50, Valid: true}})
+	for _, msg := range []string{
+		"Player #1 A (-) has been kicked by BattlEye: Global Ban #1a2b3c",
+		"Player #2 B (-) has been kicked by BattlEye: Global Ban #4d5e6f",
+		"Player #3 C (-) has been kicked by BattlEye: Client not responding",
+	} {
+		mc.HandleEvent(ParseEvent([]byte(msg)))
+	}
+
+	if !mc.SetLabels(Labels{{Key: "AAA", Value: "111"}, {Key: "BBB", Value: "333"}}) {
+		t.Fatal("expected labels change")
+	}
+
+	// totals continue with new labels, series with previous labels are removed
+	if n := testutil.CollectAndCount(mc.eventKicks); n != 2 {
+		t.Errorf("expected 2 kicks series, got %d", n)
+	}
+	if v := testutil.ToFloat64(mc.eventKicks.WithLabelValues("111", "333", "Global Ban")); v != 2 {
+		t.Errorf("expected 2 global ban kicks with new labels, got %v", v)
+	}
+	if v := testutil.ToFloat64(mc.eventKicks.WithLabelValues("111", "333", "Client not responding")); v != 1 {
+		t.Errorf("expected 1 kick with new labels, got %v", v)
+	}
+	if n := testutil.CollectAndCount(mc.playersPing); n != 0 {
+		t.Errorf("expected ping histogram with previous labels removed, got %d series", n)
+	}
+
+	mc.HandleEvent(ParseEvent([]byte("Player #4 D (-) has been kicked by BattlEye: Global Ban #7a8b9c")))
+	if v := testutil.ToFloat64(mc.eventKicks.WithLabelValues("111", "333", "Global Ban")); v != 3 {
+		t.Errorf("expected 3 global ban kicks, got %v", v)
+	}
+}
+
+func TestPlayerTracker(t *testing.T) {
+	now := time.Date(2025, 4, 20, 23, 0, 0, 0, time.UTC)
+
+	mc := NewMetricsCollector(getCustomLabels())
+	mc.InitPlayerMetrics()
+	mc.InitPlayerTrackerMetrics()
+	mc.tracker.now = func() time.Time { return now }
+
+	snapshot := func(guids ...string) *beparser.Players {
+		players := beparser.Players{}
+		for _, guid := range guids {
+			players = append(players, beparser.Player{GUID: guid, Valid: true})
+		}
+		return &players
+	}
+
+	// first snapshot is baseline
+	mc.UpdatePlayerMetrics(snapshot("A", "B"))
+	now = now.Add(30 * time.Minute)
+	mc.UpdatePlayerMetrics(snapshot("A", "C"))
+	now = now.Add(time.Hour)
+	mc.UpdatePlayerMetrics(snapshot("A"))
+
+	if v := testutil.ToFloat64(mc.playerJoins); v != 1 {
+		t.Errorf("expected 1 join, got %v", v)
+	}
+	if v := testutil.ToFloat64(mc.playerLeaves); v != 2 {
+		t.Errorf("expected 2 leaves, got %v", v)
+	}
+
+	// session of B started before baseline and is not observed
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(mc.playerSessions)
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather metrics: %v", err)
+	}
+	histogram := families[0].GetMetric()[0].GetHistogram()
+	if histogram.GetSampleCount() != 1 || histogram.GetSampleSum() != 3600 {
+		t.Errorf("expected 1 session of 3600 seconds, got %d with sum %v", histogram.GetSampleCount(), histogram.GetSampleSum())
+	}
+
+	// unique players counted from UTC midnight
+	if v := testutil.ToFloat64(mc.playersUnique); v != 1 {
+		t.Errorf("expected 1 unique player today, got %v", v)
+	}
+}
+
+func TestBansChanges(t *testing.T) {
+	mc := NewMetricsCollector(getCustomLabels())
+	mc.InitBansMetrics()
+	mc.SetBansExpiringWindow(time.Hour)
+
+	bans := &beparser.Bans{
+		GUIDBans: beparser.BansGUID{
+			{GUID: "aaa", MinutesLeft: -1, Reason: "cheating"},
+			{GUID: "bbb", MinutesLeft: 30, Reason: "toxic"},
+		},
+		IPBans: beparser.BansIP{
+			{IP: "10.0.0.1", MinutesLeft: 90},
+		},
+	}
+
+	// first update is baseline
+	if changes := mc.UpdateBansMetrics(bans); len(changes) != 0 {
+		t.Fatalf("expected no changes for baseline, got %v", changes)
+	}
+	if v := testutil.ToFloat64(mc.bansExpiring.WithLabelValues("111", "222", "guid")); v != 1 {
+		t.Errorf("expected 1 GUID ban expiring in hour, got %v", v)
+	}
+
+	bans.GUIDBans = beparser.BansGUID{
+		{GUID: "aaa", MinutesLeft: -1, Reason: "cheating"},
+		{GUID: "ccc", MinutesLeft: 600, Reason: "griefing"},
+	}
+	changes := mc.UpdateBansMetrics(bans)
+
+	want := []BanChange{
+		{Action: BanAdded, Type: "guid", Value: "ccc", Reason: "griefing", MinutesLeft: 600},
+		{Action: BanRemoved, Type: "guid", Value: "bbb", Reason: "toxic", MinutesLeft: 30},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("expected %v, got %v", want, changes)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Errorf("expected %+v, got %+v", want[i], changes[i])
+		}
+	}
+
+	if v := testutil.ToFloat64(mc.bansAdded.WithLabelValues("111", "222", "guid")); v != 1 {
+		t.Errorf("expected 1 added GUID ban, got %v", v)
+	}
+	if v := testutil.ToFloat64(mc.bansRemoved.WithLabelValues("111", "222", "guid")); v != 1 {
+		t.Errorf("expected 1 removed GUID ban, got %v", v)
+	}
+}
+
+func TestBansChangesConcurrent(t *testing.T) {
+	mc := NewMetricsCollector(getCustomLabels())
+	mc.InitBansMetrics()
+
+	// bans polling and scrape may update metrics at the same time
+	lists := []*beparser.Bans{
+		{GUIDBans: beparser.BansGUID{{GUID: "aaa", MinutesLeft: -1}}},
+		{GUIDBans: beparser.BansGUID{{GUID: "bbb", MinutesLeft: -1}}},
+	}
+	mc.UpdateBansMetrics(lists[0])
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range 50 {
+				mc.UpdateBansMetrics(lists[(i+j)%2])
+			}
+		}()
+	}
+	wg.Wait()
+
+	// each change of snapshot adds one ban and removes another
+	added := testutil.ToFloat64(mc.bansAdded.WithLabelValues("111", "222", "guid"))
+	removed := testutil.ToFloat64(mc.bansRemoved.WithLabelValues("111", "222", "guid"))
+	if added != removed || added == 0 {
+		t.Errorf("expected same non-zero count of added and removed bans, got %v added, %v removed", added, removed)
+	}
+}
+
+func TestBansAggregateMetrics(t *testing.T) {
+	mc := NewMetricsCollector(nil)
+	mc.InitBansAggregateMetrics()
+	mc.SetBanReasonsLimit(2)
+
+	mc.UpdateBansMetrics(&beparser.Bans{
+		GUIDBans: beparser.BansGUID{
+			{GUID: "a", MinutesLeft: -1, Reason: "Cheating #123"},
+			{GUID: "b", MinutesLeft: -1, Reason: "cheating #456"},
+			{GUID: "c", MinutesLeft: 120, Reason: "Toxic"},
+			{GUID: "d", MinutesLeft: 60, Reason: "Griefing"},
+		},
+		IPBans: beparser.BansIP{
+			{IP: "10.0.0.1", MinutesLeft: -1, Reason: "Toxic", Country: "DE"},
+			{IP: "10.0.0.2", MinutesLeft: -1, Reason: "VPN"},
+		},
+	})
+
+	// per-ban series are not created in aggregate mode
+	if mc.banGUIDTimeMetric != nil || mc.banIPTimeMetric != nil {
+		t.Error("expected no per-ban metrics in aggregate mode")
+	}
+
+	tests := []struct {
+		metric *prometheus.GaugeVec
+		labels []string
+		want   float64
+	}{
+		{mc.bansByTerm, []string{"guid", "permanent"}, 2},
+		{mc.bansByTerm, []string{"guid", "temporary"}, 2},
+		{mc.bansByTerm, []string{"ip", "permanent"}, 2},
+		{mc.bansByReason, []string{"guid", "cheating"}, 2},
+		{mc.bansByReason, []string{"guid", "toxic"}, 1},
+		{mc.bansByReason, []string{"guid", "other"}, 1},
+		{mc.bansByReason, []string{"ip", "other"}, 1},
+	}
+	for _, tt := range tests {
+		if v := testutil.ToFloat64(tt.metric.WithLabelValues(tt.labels...)); v != tt.want {
+			t.Errorf("%v: expected %v, got %v", tt.labels, tt.want, v)
+		}
+	}
+	if n := testutil.CollectAndCount(mc.bansByReason); n != 5 {
+		t.Errorf("expected 5 reason series with limit of 2 reasons, got %d", n)
+	}
+}
+
+func TestPlayersPingMetrics(t *testing.T) {
+	mc := NewMetricsCollector(getCustomLabels())
+	mc.InitPlayerMetrics()
+	mc.InitCountryPingMetrics()
+
+	mc.UpdatePlayerMetrics(&beparser.Players{
+		{GUID: "A", Ping: 40, Country: "DE", Valid: true},
+		{GUID: "B", Ping: 60, Country: "DE", Valid: true},
+		{GUID: "C", Ping: 250, Country: "US", Valid: true},
+		{GUID: "D", Ping: 30, Valid: true},
+		{GUID: "E", Valid: false},
+	})
+
+	expected := `
+# HELP bercon_players_ping_seconds Histogram of players ping in seconds, observed for all players on each update.
+# TYPE bercon_players_ping_seconds histogram
+bercon_players_ping_seconds_bucket{AAA="111",BBB="222",le="0.025"} 0
+bercon_players_ping_seconds_bucket{AAA="111",BBB="222",le="0.05"} 2
+bercon_players_ping_seconds_bucket{AAA="111",BBB="222",le="0.075"} 3
+bercon_players_ping_seconds_bucket{AAA="111",BBB="222",le="0.1"} 3
+bercon_players_ping_seconds_bucket{AAA="111",BBB="222",le="0.15"} 3
+bercon_players_ping_seconds_bucket{AAA="111",BBB="222",le="0.2"} 3
+bercon_players_ping_seconds_bucket{AAA="111",BBB="222",le="0.3"} 4
+bercon_players_ping_seconds_bucket{AAA="111",BBB="222",le="0.5"} 4
+bercon_players_ping_seconds_bucket{AAA="111",BBB="222",le="1"} 4
+bercon_players_ping_seconds_bucket{AAA="111",BBB="222",le="+Inf"} 4
+bercon_players_ping_seconds_sum{AAA="111",BBB="222"} 0.38
+bercon_players_ping_seconds_count{AAA="111",BBB="222"} 4
+`
+	if err := testutil.CollectAndCompare(mc.playersPing, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+
+	if v := testutil.ToFloat64(mc.countryPing.WithLabelValues("111", "222", "DE")); v != 0.05 {
+		t.Errorf("expected DE average ping 0.05, got %v", v)
+	}
+	if n := testutil.CollectAndCount(mc.countryPing); n != 2 {
+		t.Errorf("expected 2 countries, got %d", n)
+	}
+}
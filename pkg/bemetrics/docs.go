@@ -1,9 +1,17 @@
 // Package bemetrics provides a collector for DayZ server metrics, including:
 // - Server information via A2S queries (players online, slots, queue, etc.)
-// - Player statistics via BattlEye RCON (ping, online status, lobby/invalid players)
+// - Players session duration and score via A2S_PLAYER queries
+// - Server mods via A2S_RULES queries
+// - Player statistics via BattlEye RCON (ping histogram, online status, lobby/invalid players)
+// - Players sessions tracking via BattlEye RCON (joins, leaves, session duration, unique players)
+// - BattlEye server messages (connects, disconnects, kicks, chat, admin logins)
 // - Ban information via BattlEye RCON (GUID and IP bans with durations)
+// - Players and IP bans by country, continent and autonomous system with GeoIP lookup
+// - Players by approximate location with GeoIP City database
 //
 // The package exposes metrics in Prometheus format and allows customization
 // through additional labels. It handles both static server information and
 // dynamic player/ban data with proper metric initialization and updates.
+// Metrics are exposed with original v1 names, v2 names with base units or both,
+// see SetSchema.
 package bemetrics
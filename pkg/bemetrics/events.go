@@ -0,0 +1,225 @@
+package bemetrics
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// EventType is a kind of BattlEye server message
+type EventType string
+
+// BattlEye server messages types
+const (
+	EventConnect      EventType = "connect"       // player connected to server
+	EventDisconnect   EventType = "disconnect"    // player disconnected from server
+	EventGUID         EventType = "guid"          // player GUID verified
+	EventKick         EventType = "kick"          // player kicked by BattlEye or admin
+	EventChat         EventType = "chat"          // player chat message
+	EventAdminLogin   EventType = "admin_login"   // RCON admin logged in
+	EventAdminMessage EventType = "admin_message" // RCON admin message
+)
+
+// Event is a parsed BattlEye server message
+type Event struct {
+	Type    EventType `json:"type"`
+	Name    string    `json:"name,omitempty"`    // player name
+	IP      string    `json:"ip,omitempty"`      // player or admin address with port
+	GUID    string    `json:"guid,omitempty"`    // player BattlEye GUID
+	Reason  string    `json:"reason,omitempty"`  // kick reason as is
+	Channel string    `json:"channel,omitempty"` // chat channel in lower case
+	Message string    `json:"message,omitempty"` // chat message
+	ID      int       `json:"id"`                // player or admin number on server
+}
+
+var (
+	connectRegexp      = regexp.MustCompile(`^Player #(\d+) (.+) \(([^()]+)\) connected$`)
+	disconnectRegexp   = regexp.MustCompile(`^Player #(\d+) (.+) disconnected$`)
+	guidRegexp         = regexp.MustCompile(`^Verified GUID \(([0-9a-fA-F]{32})\) of player #(\d+) (.+)$`)
+	kickRegexp         = regexp.MustCompile(`^Player #(\d+) (.+) \(([0-9a-fA-F]{32}|-)\) has been kicked by BattlEye: (.+)$`)
+	chatRegexp         = regexp.MustCompile(`^\((Global|Side|Direct|Vehicle|Group|Command|Unknown)\) (.+?): (.*)$`)
+	adminLoginRegexp   = regexp.MustCompile(`^RCon admin #(\d+) \(([^()]+)\) logged in$`)
+	adminMessageRegexp = regexp.MustCompile(`^RCon admin #(\d+): \((.+?)\) (.*)$`)
+
+	// variable parts of kick reasons, like ban IDs, numbers and details in brackets
+	kickDetailsRegexp = regexp.MustCompile(`\s*(\(.*\)|\[.*\]|#\S+|\d+)`)
+)
+
+// KickReason maps kick reasons matched by regular expression to reason category,
+// reason can refer to submatches like $1 as in regexp.Regexp.Expand
+type KickReason struct {
+	Match  *regexp.Regexp
+	Reason string
+}
+
+// ParseEvent parse BattlEye server message, returns nil for unknown messages
+func ParseEvent(data []byte) *Event {
+	msg := strings.TrimSpace(string(data))
+
+	if m := kickRegexp.FindStringSubmatch(msg); m != nil {
+		return &Event{Type: EventKick, ID: atoi(m[1]), Name: m[2], GUID: guid(m[3]), Reason: m[4]}
+	}
+	if m := connectRegexp.FindStringSubmatch(msg); m != nil {
+		return &Event{Type: EventConnect, ID: atoi(m[1]), Name: m[2], IP: m[3]}
+	}
+	if m := disconnectRegexp.FindStringSubmatch(msg); m != nil {
+		return &Event{Type: EventDisconnect, ID: atoi(m[1]), Name: m[2]}
+	}
+	if m := guidRegexp.FindStringSubmatch(msg); m != nil {
+		return &Event{Type: EventGUID, ID: atoi(m[2]), Name: m[3], GUID: guid(m[1])}
+	}
+	if m := chatRegexp.FindStringSubmatch(msg); m != nil {
+		return &Event{Type: EventChat, Channel: strings.ToLower(m[1]), Name: m[2], Message: m[3]}
+	}
+	if m := adminLoginRegexp.FindStringSubmatch(msg); m != nil {
+		return &Event{Type: EventAdminLogin, ID: atoi(m[1]), IP: m[2]}
+	}
+	if m := adminMessageRegexp.FindStringSubmatch(msg); m != nil {
+		return &Event{Type: EventAdminMessage, ID: atoi(m[1]), Channel: strings.ToLower(m[2]), Message: m[3]}
+	}
+
+	return nil
+}
+
+// NormalizeKickReason returns kick reason without variable details suitable for metric label,
+// e.g. "Admin Ban (cheating)" and "Global Ban #1a2b3c" become "Admin Ban" and "Global Ban"
+func NormalizeKickReason(reason string) string {
+	reason = kickDetailsRegexp.ReplaceAllString(reason, "")
+	reason = strings.TrimRight(strings.TrimSpace(reason), ".:")
+	if reason == "" {
+		return "unknown"
+	}
+
+	return reason
+}
+
+// SetKickReasons use for set rules for kick reasons categories, first matched rule is used.
+// Not matched reasons are normalized by NormalizeKickReason or replaced with other if it set.
+func (mc *MetricsCollector) SetKickReasons(reasons []KickReason, other string) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.kickReasons = reasons
+	mc.kickReasonOther = other
+}
+
+// return kick reason category for metric label
+func (mc *MetricsCollector) kickReason(reason string) string {
+	for _, rule := range mc.kickReasons {
+		if match := rule.Match.FindStringSubmatchIndex(reason); match != nil {
+			return string(rule.Match.ExpandString(nil, rule.Reason, reason, match))
+		}
+	}
+
+	if mc.kickReasonOther != "" {
+		return mc.kickReasonOther
+	}
+
+	return NormalizeKickReason(reason)
+}
+
+// InitEventMetrics initialize bercon server messages metrics
+func (mc *MetricsCollector) InitEventMetrics() {
+	labels := mc.customLabels.Keys()
+
+	if mc.eventConnects == nil {
+		mc.eventConnects = prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "bercon_player_connects_total",
+				Help: "Total count of players connections from server messages.",
+			},
+			labels,
+		)
+	}
+
+	if mc.eventDisconnects == nil {
+		mc.eventDisconnects = prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "bercon_player_disconnects_total",
+				Help: "Total count of players disconnections from server messages.",
+			},
+			labels,
+		)
+	}
+
+	if mc.eventKicks == nil {
+		mc.eventKicks = prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "bercon_kicks_total",
+				Help: "Total count of players kicks by reason.",
+			},
+			append(labels, "reason"),
+		)
+	}
+
+	if mc.eventChat == nil {
+		mc.eventChat = prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "bercon_chat_messages_total",
+				Help: "Total count of chat messages by channel.",
+			},
+			append(labels, "channel"),
+		)
+	}
+
+	if mc.eventAdminLogins == nil {
+		mc.eventAdminLogins = prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "bercon_admin_logins_total",
+				Help: "Total count of RCON admins logins.",
+			},
+			labels,
+		)
+	}
+}
+
+// HandleEvent use for update bercon server messages metrics with parsed event
+func (mc *MetricsCollector) HandleEvent(event *Event) {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+
+	values := mc.customLabels.Values()
+
+	switch event.Type {
+	case EventConnect:
+		if mc.eventConnects != nil {
+			mc.eventConnects.WithLabelValues(values...).Inc()
+		}
+	case EventDisconnect:
+		if mc.eventDisconnects != nil {
+			mc.eventDisconnects.WithLabelValues(values...).Inc()
+		}
+	case EventKick:
+		if mc.eventKicks != nil {
+			mc.eventKicks.WithLabelValues(append(values, mc.kickReason(event.Reason))...).Inc()
+		}
+	case EventChat:
+		if mc.eventChat != nil {
+			mc.eventChat.WithLabelValues(append(values, event.Channel)...).Inc()
+		}
+	case EventAdminMessage:
+		if mc.eventChat != nil {
+			mc.eventChat.WithLabelValues(append(values, "rcon")...).Inc()
+		}
+	case EventAdminLogin:
+		if mc.eventAdminLogins != nil {
+			mc.eventAdminLogins.WithLabelValues(values...).Inc()
+		}
+	}
+}
+
+// convert regexp matched number
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// return GUID in lower case, empty for not verified "-" GUID
+func guid(s string) string {
+	if s == "-" {
+		return ""
+	}
+	return strings.ToLower(s)
+}
@@ -0,0 +1,131 @@
+package bemetrics
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestParseEvent(t *testing.T) {
+	tests := []struct {
+		msg  string
+		want *Event
+	}{
+		{
+			msg:  "Player #3 Avtonom Fedenko (175.78.137.224:46534) connected",
+			want: &Event{Type: EventConnect, ID: 3, Name: "Avtonom Fedenko", IP: "175.78.137.224:46534"},
+		},
+		{
+			msg:  "Verified GUID (20501A3C348F41D8B7AC3F4D1BB2B11C) of player #3 Avtonom Fedenko",
+			want: &Event{Type: EventGUID, ID: 3, Name: "Avtonom Fedenko", GUID: "20501a3c348f41d8b7ac3f4d1bb2b11c"},
+		},
+		{
+			msg:  "Player #3 Avtonom Fedenko disconnected",
+			want: &Event{Type: EventDisconnect, ID: 3, Name: "Avtonom Fedenko"},
+		},
+		{
+			msg:  "Player #1 Svitlogor (A3333BB4AFBC64F07F1FA0C6C09E6746) has been kicked by BattlEye: Client not responding",
+			want: &Event{Type: EventKick, ID: 1, Name: "Svitlogor", GUID: "a3333bb4afbc64f07f1fa0c6c09e6746", Reason: "Client not responding"},
+		},
+		{
+			msg:  "Player #1 Svitlogor (-) has been kicked by BattlEye: Invalid GUID",
+			want: &Event{Type: EventKick, ID: 1, Name: "Svitlogor", Reason: "Invalid GUID"},
+		},
+		{
+			msg:  "(Global) Sergiy Filevich: hello: world",
+			want: &Event{Type: EventChat, Channel: "global", Name: "Sergiy Filevich", Message: "hello: world"},
+		},
+		{
+			msg:  "RCon admin #0 (127.0.0.1:51234) logged in",
+			want: &Event{Type: EventAdminLogin, IP: "127.0.0.1:51234"},
+		},
+		{
+			msg:  "RCon admin #0: (To Everyone) Restart in 5 minutes",
+			want: &Event{Type: EventAdminMessage, Channel: "to everyone", Message: "Restart in 5 minutes"},
+		},
+		{
+			msg: "Something unexpected",
+		},
+	}
+
+	for _, tt := range tests {
+		got := ParseEvent([]byte(tt.msg))
+		if tt.want == nil {
+			if got != nil {
+				t.Errorf("%q: expected unknown message, got %+v", tt.msg, got)
+			}
+			continue
+		}
+		if got == nil || *got != *tt.want {
+			t.Errorf("%q: expected %+v, got %+v", tt.msg, tt.want, got)
+		}
+	}
+}
+
+func TestNormalizeKickReason(t *testing.T) {
+	tests := map[string]string{
+		"Client not responding":           "Client not responding",
+		"Admin Ban (cheating)":            "Admin Ban",
+		"Global Ban #1a2b3c":              "Global Ban",
+		"Script Restriction #45":          "Script Restriction",
+		"Kicked by admin [reason: spam].": "Kicked by admin",
+		"":                                "unknown",
+	}
+
+	for reason, want := range tests {
+		if got := NormalizeKickReason(reason); got != want {
+			t.Errorf("%q: expected %q, got %q", reason, want, got)
+		}
+	}
+}
+
+func TestHandleEvent(t *testing.T) {
+	mc := NewMetricsCollector(getCustomLabels())
+	mc.InitEventMetrics()
+
+	for _, msg := range []string{
+		"Player #1 A (-) has been kicked by BattlEye: Global Ban #1a2b3c",
+		"Player #2 B (-) has been kicked by BattlEye: Global Ban #4d5e6f",
+		"(Side) A: hi",
+		"Player #1 A disconnected",
+	} {
+		mc.HandleEvent(ParseEvent([]byte(msg)))
+	}
+
+	if v := testutil.ToFloat64(mc.eventKicks.WithLabelValues("111", "222", "Global Ban")); v != 2 {
+		t.Errorf("expected 2 global ban kicks, got %v", v)
+	}
+	if v := testutil.ToFloat64(mc.eventChat.WithLabelValues("111", "222", "side")); v != 1 {
+		t.Errorf("expected 1 side chat message, got %v", v)
+	}
+	if v := testutil.ToFloat64(mc.eventDisconnects); v != 1 {
+		t.Errorf("expected 1 disconnect, got %v", v)
+	}
+}
+
+func TestKickReasons(t *testing.T) {
+	mc := NewMetricsCollector(nil)
+	mc.SetKickReasons([]KickReason{
+		{Match: regexp.MustCompile(`^(\w+) Restriction #\d+`), Reason: "$1 restriction"},
+		{Match: regexp.MustCompile(`(?i)^admin (kick|ban)`), Reason: "admin"},
+	}, "")
+
+	tests := map[string]string{
+		"Script Restriction #45":    "Script restriction",
+		"Admin Kick (spam)":         "admin",
+		"Client not responding":     "Client not responding",
+		"Global Ban #1a2b3c":        "Global Ban",
+		"RemoteExec Restriction #0": "RemoteExec restriction",
+	}
+	for reason, want := range tests {
+		if got := mc.kickReason(reason); got != want {
+			t.Errorf("%q: expected %q, got %q", reason, want, got)
+		}
+	}
+
+	mc.SetKickReasons(nil, "other")
+	if got := mc.kickReason("Client not responding"); got != "other" {
+		t.Errorf("expected not matched reason replaced with other, got %q", got)
+	}
+}
@@ -0,0 +1,257 @@
+package bemetrics
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/woozymasta/bercon-cli/pkg/beparser"
+)
+
+// default count of decimal digits of players location coordinates, about 11 km
+const defaultLocationPrecision = 1
+
+// unknownGeo is a country or continent code for IP addresses not found in GeoIP database,
+// the same as used by beparser SetCountryCode
+const unknownGeo = "XX"
+
+// GeoInfo is a location of IP address
+type GeoInfo struct {
+	Country     string  `json:"country,omitempty"`     // ISO country code
+	Continent   string  `json:"continent,omitempty"`   // continent code
+	ASOrg       string  `json:"as_org,omitempty"`      // autonomous system organization
+	City        string  `json:"city,omitempty"`        // city name in English
+	Subdivision string  `json:"subdivision,omitempty"` // most specific subdivision name in English
+	Latitude    float64 `json:"latitude,omitempty"`    // approximate latitude, 0 with longitude for unknown location
+	Longitude   float64 `json:"longitude,omitempty"`   // approximate longitude
+	ASN         uint    `json:"asn,omitempty"`         // autonomous system number
+}
+
+// geo metrics of players or IP bans
+type geoMetrics struct {
+	byCountry   *prometheus.GaugeVec
+	byContinent *prometheus.GaugeVec
+	byASN       *prometheus.GaugeVec
+	byLocation  *prometheus.GaugeVec
+	precision   int // decimal digits of location coordinates
+}
+
+// GeoLookup returns location of IP address, nil if IP address is not found
+type GeoLookup func(ip string) *GeoInfo
+
+// SetGeoLookup use for set IP addresses location lookup used by geo metrics
+func (mc *MetricsCollector) SetGeoLookup(lookup GeoLookup) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.geoLookup = lookup
+}
+
+// InitGeoMetrics initialize players and IP bans by country and continent metrics,
+// locations are resolved by lookup from SetGeoLookup
+func (mc *MetricsCollector) InitGeoMetrics() {
+	labels := mc.customLabels.Keys()
+
+	if mc.playersByCountry == nil {
+		mc.playersByCountry = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "dayz_players_by_country",
+				Help: "Count of players by country.",
+			},
+			append(labels, "country"),
+		)
+	}
+
+	if mc.playersByContinent == nil {
+		mc.playersByContinent = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "dayz_players_by_continent",
+				Help: "Count of players by continent.",
+			},
+			append(labels, "continent"),
+		)
+	}
+
+	if mc.bansByCountry == nil {
+		mc.bansByCountry = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "dayz_bans_by_country",
+				Help: "Count of IP bans by country.",
+			},
+			append(labels, "country"),
+		)
+	}
+
+	if mc.bansByContinent == nil {
+		mc.bansByContinent = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "dayz_bans_by_continent",
+				Help: "Count of IP bans by continent.",
+			},
+			append(labels, "continent"),
+		)
+	}
+}
+
+// InitASNMetrics initialize players and IP bans by autonomous system metrics,
+// autonomous systems are resolved by lookup from SetGeoLookup
+func (mc *MetricsCollector) InitASNMetrics() {
+	labels := mc.customLabels.Keys()
+
+	if mc.playersByASN == nil {
+		mc.playersByASN = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "dayz_players_by_asn",
+				Help: "Count of players by autonomous system.",
+			},
+			append(labels, "asn", "as_org"),
+		)
+	}
+
+	if mc.bansByASN == nil {
+		mc.bansByASN = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "dayz_bans_by_asn",
+				Help: "Count of IP bans by autonomous system.",
+			},
+			append(labels, "asn", "as_org"),
+		)
+	}
+}
+
+// InitLocationMetrics initialize players by approximate location metrics for GeoIP City database,
+// locations are resolved by lookup from SetGeoLookup
+func (mc *MetricsCollector) InitLocationMetrics() {
+	if mc.playersByLocation == nil {
+		mc.playersByLocation = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "dayz_players_by_location",
+				Help: "Count of players by approximate location, coordinates are rounded for privacy.",
+			},
+			append(mc.customLabels.Keys(), "country", "subdivision", "city", "latitude", "longitude"),
+		)
+	}
+}
+
+// SetLocationPrecision use for set count of decimal digits of players location coordinates,
+// 1 by default (about 11 km), 0 is about 111 km, each next digit is 10 times more precise
+func (mc *MetricsCollector) SetLocationPrecision(digits int) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	if digits >= 0 {
+		mc.locationPrecision = digits
+	}
+}
+
+// return location of IP address, country from beparser is used if lookup is not set or IP is not found
+func (mc *MetricsCollector) lookupGeo(ip, country string) GeoInfo {
+	geo := GeoInfo{Country: country}
+	if mc.geoLookup != nil {
+		if info := mc.geoLookup(ip); info != nil {
+			geo = *info
+			if geo.Country == "" {
+				geo.Country = country
+			}
+		}
+	}
+
+	if geo.Country == "" {
+		geo.Country = unknownGeo
+	}
+	if geo.Continent == "" {
+		geo.Continent = unknownGeo
+	}
+
+	return geo
+}
+
+// return locations of players
+func (mc *MetricsCollector) lookupPlayers(players []beparser.Player) []GeoInfo {
+	locations := make([]GeoInfo, 0, len(players))
+	for _, player := range players {
+		locations = append(locations, mc.lookupGeo(player.IP, player.Country))
+	}
+
+	return locations
+}
+
+// return locations of IP bans
+func (mc *MetricsCollector) lookupBans(bans []beparser.BanIP) []GeoInfo {
+	locations := make([]GeoInfo, 0, len(bans))
+	for _, ban := range bans {
+		locations = append(locations, mc.lookupGeo(ban.IP, ban.Country))
+	}
+
+	return locations
+}
+
+// set count of locations by country, continent and autonomous system,
+// count of metrics is dynamic, they are reset always
+func (m geoMetrics) update(locations []GeoInfo, values []string) {
+	type asKey struct {
+		asn, org string
+	}
+	type placeKey struct {
+		country, subdivision, city, latitude, longitude string
+	}
+	countries := make(map[string]float64)
+	continents := make(map[string]float64)
+	systems := make(map[asKey]float64)
+	places := make(map[placeKey]float64)
+	for _, geo := range locations {
+		countries[geo.Country]++
+		continents[geo.Continent]++
+		systems[asKey{geo.asnLabel(), geo.ASOrg}]++
+		if geo.Latitude != 0 || geo.Longitude != 0 {
+			places[placeKey{
+				geo.Country, geo.Subdivision, geo.City,
+				roundCoordinate(geo.Latitude, m.precision), roundCoordinate(geo.Longitude, m.precision),
+			}]++
+		}
+	}
+
+	if m.byCountry != nil {
+		m.byCountry.Reset()
+		for country, count := range countries {
+			m.byCountry.WithLabelValues(append(values, country)...).Set(count)
+		}
+	}
+
+	if m.byContinent != nil {
+		m.byContinent.Reset()
+		for continent, count := range continents {
+			m.byContinent.WithLabelValues(append(values, continent)...).Set(count)
+		}
+	}
+
+	if m.byASN != nil {
+		m.byASN.Reset()
+		for as, count := range systems {
+			m.byASN.WithLabelValues(append(values, as.asn, as.org)...).Set(count)
+		}
+	}
+
+	if m.byLocation != nil {
+		m.byLocation.Reset()
+		for place, count := range places {
+			placeLabels := append(values, place.country, place.subdivision, place.city, place.latitude, place.longitude)
+			m.byLocation.WithLabelValues(placeLabels...).Set(count)
+		}
+	}
+}
+
+// return coordinate rounded to decimal digits for metric label
+func roundCoordinate(coordinate float64, digits int) string {
+	scale := math.Pow10(digits)
+	return strconv.FormatFloat(math.Round(coordinate*scale)/scale, 'f', digits, 64)
+}
+
+// return autonomous system number for metric label, empty if unknown
+func (g GeoInfo) asnLabel() string {
+	if g.ASN == 0 {
+		return ""
+	}
+
+	return strconv.FormatUint(uint64(g.ASN), 10)
+}
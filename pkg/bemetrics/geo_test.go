@@ -0,0 +1,144 @@
+package bemetrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/woozymasta/bercon-cli/pkg/beparser"
+)
+
+func TestGeoMetrics(t *testing.T) {
+	mc := NewMetricsCollector(nil)
+	mc.InitGeoMetrics()
+	mc.SetGeoLookup(func(ip string) *GeoInfo {
+		switch ip {
+		case "10.0.0.1":
+			return &GeoInfo{Country: "DE", Continent: "EU"}
+		case "10.0.0.2":
+			return &GeoInfo{Country: "FR", Continent: "EU"}
+		case "10.0.0.3":
+			return &GeoInfo{Country: "US", Continent: "NA"}
+		}
+		return nil
+	})
+
+	mc.UpdatePlayerMetrics(&beparser.Players{
+		{IP: "10.0.0.1", Valid: true},
+		{IP: "10.0.0.2", Valid: true},
+		{IP: "10.0.0.3", Valid: true},
+		{IP: "10.0.0.4", Country: "XX", Valid: true},
+	})
+	mc.UpdateBansMetrics(&beparser.Bans{
+		IPBans: beparser.BansIP{{IP: "10.0.0.3"}, {IP: "10.0.0.9"}},
+	})
+
+	tests := []struct {
+		metric *prometheus.GaugeVec
+		label  string
+		want   float64
+	}{
+		{mc.playersByCountry, "DE", 1},
+		{mc.playersByCountry, "XX", 1},
+		{mc.playersByContinent, "EU", 2},
+		{mc.playersByContinent, "NA", 1},
+		{mc.playersByContinent, "XX", 1},
+		{mc.bansByCountry, "US", 1},
+		{mc.bansByCountry, "XX", 1},
+		{mc.bansByContinent, "NA", 1},
+	}
+	for _, tt := range tests {
+		if v := testutil.ToFloat64(tt.metric.WithLabelValues(tt.label)); v != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.label, tt.want, v)
+		}
+	}
+
+	// players left, stale locations are removed
+	mc.UpdatePlayerMetrics(&beparser.Players{{IP: "10.0.0.1", Valid: true}})
+	if n := testutil.CollectAndCount(mc.playersByCountry); n != 1 {
+		t.Errorf("expected 1 country, got %d", n)
+	}
+}
+
+func TestASNMetrics(t *testing.T) {
+	mc := NewMetricsCollector(nil)
+	mc.InitPlayerPingMetrics()
+	mc.InitBansMetrics()
+	mc.InitASNMetrics()
+	mc.SetGeoLookup(func(ip string) *GeoInfo {
+		if ip == "10.0.0.1" {
+			return &GeoInfo{ASN: 64500, ASOrg: "Example Hosting"}
+		}
+		return nil
+	})
+
+	mc.UpdatePlayerMetrics(&beparser.Players{
+		{Name: "A", IP: "10.0.0.1", Country: "DE", Ping: 30, Valid: true},
+		{Name: "B", IP: "10.0.0.1", Country: "DE", Ping: 40, Valid: true},
+		{Name: "C", IP: "10.0.0.2", Ping: 50, Valid: true},
+	})
+	mc.UpdateBansMetrics(&beparser.Bans{IPBans: beparser.BansIP{{IP: "10.0.0.1", MinutesLeft: -1, Reason: "VPN"}}})
+
+	if v := testutil.ToFloat64(mc.playersByASN.WithLabelValues("64500", "Example Hosting")); v != 2 {
+		t.Errorf("expected 2 players from AS64500, got %v", v)
+	}
+	if v := testutil.ToFloat64(mc.playersByASN.WithLabelValues("", "")); v != 1 {
+		t.Errorf("expected 1 player from unknown AS, got %v", v)
+	}
+	if v := testutil.ToFloat64(mc.bansByASN.WithLabelValues("64500", "Example Hosting")); v != 1 {
+		t.Errorf("expected 1 ban from AS64500, got %v", v)
+	}
+
+	// country from beparser is kept when lookup has no country
+	if v := testutil.ToFloat64(mc.playerPingMetric.WithLabelValues("A", "10.0.0.1", "", "false", "DE", "64500", "Example Hosting")); v != 30 {
+		t.Errorf("expected ping series with AS labels, got %v", v)
+	}
+	if v := testutil.ToFloat64(mc.banIPTimeMetric.WithLabelValues("VPN", "10.0.0.1", "", "64500", "Example Hosting")); v != -1 {
+		t.Errorf("expected IP ban series with AS labels, got %v", v)
+	}
+}
+
+func TestLocationMetrics(t *testing.T) {
+	mc := NewMetricsCollector(nil)
+	mc.InitLocationMetrics()
+	mc.SetGeoLookup(func(ip string) *GeoInfo {
+		switch ip {
+		case "10.0.0.1":
+			return &GeoInfo{Country: "DE", Subdivision: "Berlin", City: "Berlin", Latitude: 52.5196, Longitude: 13.4069}
+		case "10.0.0.2":
+			return &GeoInfo{Country: "DE", Subdivision: "Berlin", City: "Berlin", Latitude: 52.4811, Longitude: 13.3542}
+		case "10.0.0.3":
+			return &GeoInfo{Country: "DE"} // location is unknown
+		}
+		return nil
+	})
+
+	players := &beparser.Players{{IP: "10.0.0.1"}, {IP: "10.0.0.2"}, {IP: "10.0.0.3"}}
+	mc.UpdatePlayerMetrics(players)
+
+	// both players are rounded to the same place with default precision
+	if v := testutil.ToFloat64(mc.playersByLocation.WithLabelValues("DE", "Berlin", "Berlin", "52.5", "13.4")); v != 2 {
+		t.Errorf("expected 2 players in rounded location, got %v", v)
+	}
+	if n := testutil.CollectAndCount(mc.playersByLocation); n != 1 {
+		t.Errorf("expected 1 location, got %d", n)
+	}
+
+	mc.SetLocationPrecision(2)
+	mc.UpdatePlayerMetrics(players)
+	if n := testutil.CollectAndCount(mc.playersByLocation); n != 2 {
+		t.Errorf("expected 2 locations with precision of 2 digits, got %d", n)
+	}
+	if v := testutil.ToFloat64(mc.playersByLocation.WithLabelValues("DE", "Berlin", "Berlin", "52.48", "13.35")); v != 1 {
+		t.Errorf("expected 1 player in location, got %v", v)
+	}
+
+	// whole degrees
+	mc.SetLocationPrecision(0)
+	mc.UpdatePlayerMetrics(players)
+	for _, lat := range []string{"52", "53"} {
+		if v := testutil.ToFloat64(mc.playersByLocation.WithLabelValues("DE", "Berlin", "Berlin", lat, "13")); v != 1 {
+			t.Errorf("expected 1 player in location rounded to whole degrees %s, got %v", lat, v)
+		}
+	}
+}
@@ -7,18 +7,24 @@ import (
 	"github.com/woozymasta/bercon-cli/pkg/beparser"
 )
 
+// buckets of players ping histogram in seconds
+var pingBuckets = []float64{.025, .05, .075, .1, .15, .2, .3, .5, 1}
+
 // InitPlayerMetrics initialize bercon players metrics
 func (mc *MetricsCollector) InitPlayerMetrics() {
 	labels := mc.customLabels.Keys()
 
-	if mc.playerPingMetric == nil {
-		mc.playerPingMetric = prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Name: "bercon_player_ping_seconds",
-				Help: "Ping of players in seconds.",
-			},
-			append(labels, "name", "ip", "guid", "lobby", "country"),
-		)
+	if mc.playersPing == nil {
+		opts := prometheus.HistogramOpts{
+			Name:    "bercon_players_ping_seconds",
+			Help:    "Histogram of players ping in seconds, observed for all players on each update.",
+			Buckets: pingBuckets,
+		}
+		if mc.pingNative {
+			opts.NativeHistogramBucketFactor = 1.1
+			opts.NativeHistogramMaxBucketNumber = 100
+		}
+		mc.playersPing = prometheus.NewHistogramVec(opts, labels)
 	}
 
 	if mc.playersTotal == nil {
@@ -62,20 +68,75 @@ func (mc *MetricsCollector) InitPlayerMetrics() {
 	}
 }
 
+// InitPlayerPingMetrics initialize bercon ping metrics for each player,
+// produces series per player, prefer players ping histogram from InitPlayerMetrics
+func (mc *MetricsCollector) InitPlayerPingMetrics() {
+	if mc.playerPingMetric == nil {
+		mc.playerPingMetric = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "bercon_player_ping_seconds",
+				Help: "Ping of players in seconds.",
+			},
+			append(mc.customLabels.Keys(), "name", "ip", "guid", "lobby", "country", "asn", "as_org"),
+		)
+	}
+}
+
+// InitCountryPingMetrics initialize bercon average players ping by country metrics,
+// makes sense only with players country codes set by GeoIP
+func (mc *MetricsCollector) InitCountryPingMetrics() {
+	if mc.countryPing == nil {
+		mc.countryPing = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "bercon_players_country_ping_seconds",
+				Help: "Average ping of players by country in seconds.",
+			},
+			append(mc.customLabels.Keys(), "country"),
+		)
+	}
+}
+
+// SetPingNativeHistogram use for expose players ping as native histogram in addition to classic buckets,
+// must be called before InitPlayerMetrics
+func (mc *MetricsCollector) SetPingNativeHistogram(enabled bool) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.pingNative = enabled
+}
+
 // UpdatePlayerMetrics use for update bercon players metrics
 func (mc *MetricsCollector) UpdatePlayerMetrics(players *beparser.Players) {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+
 	values := mc.customLabels.Values()
+	locations := mc.lookupPlayers(*players)
 
 	if mc.playerPingMetric != nil {
 		mc.playerPingMetric.Reset() // count of metrics is dynamic, reset it always
 
-		for _, player := range *players {
+		// players with masked personal data may share series, it shows their average ping
+		series := mergedSeries{}
+		for i, player := range *players {
 			lobby := fmt.Sprintf("%t", player.Lobby)
-			playerLabels := append(values, player.Name, player.IP, player.GUID, lobby, player.Country)
-			mc.playerPingMetric.WithLabelValues(playerLabels...).Set(float64(player.Ping))
+			playerLabels := append(values,
+				mc.privacy.MaskName(player.Name), mc.privacy.MaskIP(player.IP), mc.privacy.MaskGUID(player.GUID),
+				lobby, player.Country, locations[i].asnLabel(), locations[i].ASOrg)
+			series.add(playerLabels, float64(player.Ping))
 		}
+		series.set(mc.playerPingMetric, average)
 	}
 
+	mc.updatePingMetrics(*players, values)
+	geoMetrics{
+		byCountry:   mc.playersByCountry,
+		byContinent: mc.playersByContinent,
+		byASN:       mc.playersByASN,
+		byLocation:  mc.playersByLocation,
+		precision:   mc.locationPrecision,
+	}.update(locations, values)
+
 	online, lobby, invalid := countPlayers(*players)
 
 	if mc.playersTotal != nil {
@@ -93,6 +154,47 @@ func (mc *MetricsCollector) UpdatePlayerMetrics(players *beparser.Players) {
 	if mc.playersInvalid != nil {
 		mc.playersInvalid.WithLabelValues(values...).Set(invalid)
 	}
+
+	mc.trackPlayers(*players, values)
+}
+
+// observe players ping histogram and update average ping by country
+func (mc *MetricsCollector) updatePingMetrics(players []beparser.Player, values []string) {
+	type countryPing struct {
+		sum   float64
+		count int
+	}
+	countries := make(map[string]*countryPing)
+
+	for _, player := range players {
+		if player.Ping == 0 {
+			continue // ping is unknown or not parsed
+		}
+		ping := float64(player.Ping) / 1000
+
+		if mc.playersPing != nil {
+			mc.playersPing.WithLabelValues(values...).Observe(ping)
+		}
+
+		if player.Country == "" {
+			continue
+		}
+		if countries[player.Country] == nil {
+			countries[player.Country] = &countryPing{}
+		}
+		countries[player.Country].sum += ping
+		countries[player.Country].count++
+	}
+
+	if mc.countryPing == nil {
+		return
+	}
+
+	mc.countryPing.Reset() // count of metrics is dynamic, reset it always
+
+	for code, ping := range countries {
+		mc.countryPing.WithLabelValues(append(values, code)...).Set(ping.sum / float64(ping.count))
+	}
 }
 
 // return online/lobby/invalid players count
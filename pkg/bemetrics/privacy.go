@@ -0,0 +1,149 @@
+package bemetrics
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Policy is a way to publish personal data of players in metrics labels
+type Policy string
+
+// personal data policies
+const (
+	PolicyKeep     Policy = "keep"     // publish as is
+	PolicyDrop     Policy = "drop"     // replace with empty value, series sharing labels are merged
+	PolicyTruncate Policy = "truncate" // IP network only, /24 for IPv4 and /48 for IPv6
+	PolicyHash     Policy = "hash"     // salted SHA-256 hash
+)
+
+// Privacy contains policies for players personal data in metrics labels, empty policy means keep
+type Privacy struct {
+	IP   Policy
+	GUID Policy
+	Name Policy
+	Salt string // salt for hash policy
+}
+
+// mergedSeries groups values of per-player or per-ban series by labels values, players and bans
+// with personal data dropped or truncated by policy may share labels and must not overwrite each other
+type mergedSeries map[string]*merged
+
+// merged contains values of players or bans sharing one series
+type merged struct {
+	labels []string
+	values []float64
+}
+
+// add value of player or ban to series with labels
+func (s mergedSeries) add(labels []string, value float64) {
+	key := strings.Join(labels, "\x00")
+	if s[key] == nil {
+		s[key] = &merged{labels: slices.Clone(labels)}
+	}
+	s[key].values = append(s[key].values, value)
+}
+
+// set gauge series to values combined by function
+func (s mergedSeries) set(vec *prometheus.GaugeVec, combine func([]float64) float64) {
+	for _, m := range s {
+		vec.WithLabelValues(m.labels...).Set(combine(m.values))
+	}
+}
+
+// return average of values
+func average(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+
+	return sum / float64(len(values))
+}
+
+// return longest ban time, permanent bans marked as -1 are longest
+func longestBan(values []float64) float64 {
+	if slices.Contains(values, -1) {
+		return -1
+	}
+
+	return slices.Max(values)
+}
+
+// ParsePolicy returns policy by name, truncate is allowed only for IP
+func ParsePolicy(name string, ip bool) (Policy, error) {
+	switch policy := Policy(name); policy {
+	case "", PolicyKeep:
+		return PolicyKeep, nil
+	case PolicyDrop, PolicyHash:
+		return policy, nil
+	case PolicyTruncate:
+		if ip {
+			return policy, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown policy %q", name)
+}
+
+// SetPrivacy use for set policies for players personal data in metrics labels
+func (mc *MetricsCollector) SetPrivacy(privacy Privacy) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.privacy = privacy
+}
+
+// MaskIP returns IP address according to policy
+func (p Privacy) MaskIP(ip string) string {
+	if p.IP == PolicyTruncate {
+		return truncateIP(ip)
+	}
+
+	return p.apply(p.IP, ip)
+}
+
+// MaskGUID returns BattlEye GUID according to policy
+func (p Privacy) MaskGUID(guid string) string {
+	return p.apply(p.GUID, guid)
+}
+
+// MaskName returns player name according to policy
+func (p Privacy) MaskName(name string) string {
+	return p.apply(p.Name, name)
+}
+
+// apply keep, drop or hash policy to value
+func (p Privacy) apply(policy Policy, value string) string {
+	switch policy {
+	case PolicyDrop:
+		return ""
+	case PolicyHash:
+		if value == "" {
+			return ""
+		}
+		sum := sha256.Sum256([]byte(p.Salt + value))
+		return hex.EncodeToString(sum[:8])
+	default:
+		return value
+	}
+}
+
+// return network address of IP, /24 for IPv4 and /48 for IPv6, empty for invalid IP
+func truncateIP(ip string) string {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return ""
+	}
+
+	if v4 := addr.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(24, 32)).String()
+	}
+
+	return addr.Mask(net.CIDRMask(48, 128)).String()
+}
@@ -0,0 +1,119 @@
+package bemetrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/woozymasta/a2s/pkg/a2s"
+	"github.com/woozymasta/bercon-cli/pkg/beparser"
+)
+
+func TestPrivacyMask(t *testing.T) {
+	privacy := Privacy{IP: PolicyTruncate, GUID: PolicyHash, Name: PolicyDrop, Salt: "salt"}
+
+	for ip, want := range map[string]string{
+		"192.168.1.42":  "192.168.1.0",
+		"2001:db8:1::1": "2001:db8:1::",
+		"invalid":       "",
+	} {
+		if got := privacy.MaskIP(ip); got != want {
+			t.Errorf("MaskIP(%q) = %q, want %q", ip, got, want)
+		}
+	}
+
+	guid := privacy.MaskGUID("0123456789abcdef0123456789abcdef")
+	if len(guid) != 16 || guid == "0123456789abcdef" {
+		t.Errorf("unexpected hashed GUID %q", guid)
+	}
+	if other := (Privacy{GUID: PolicyHash, Salt: "other"}).MaskGUID("0123456789abcdef0123456789abcdef"); other == guid {
+		t.Errorf("hash does not depend on salt")
+	}
+	if name := privacy.MaskName("Survivor"); name != "" {
+		t.Errorf("expected dropped name, got %q", name)
+	}
+	if name := (Privacy{}).MaskName("Survivor"); name != "Survivor" {
+		t.Errorf("expected kept name by default, got %q", name)
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	if _, err := ParsePolicy("truncate", false); err == nil {
+		t.Error("expected error for truncate policy of not IP value")
+	}
+	if _, err := ParsePolicy("unknown", true); err == nil {
+		t.Error("expected error for unknown policy")
+	}
+	if policy, err := ParsePolicy("", false); err != nil || policy != PolicyKeep {
+		t.Errorf("expected keep policy by default, got %q, %v", policy, err)
+	}
+}
+
+func TestPrivacyPlayerMetrics(t *testing.T) {
+	mc := NewMetricsCollector(nil)
+	mc.InitPlayerPingMetrics()
+	mc.SetPrivacy(Privacy{IP: PolicyTruncate, GUID: PolicyDrop, Name: PolicyDrop})
+
+	mc.UpdatePlayerMetrics(&beparser.Players{
+		{Name: "Survivor", IP: "10.0.0.7", GUID: "0123456789abcdef0123456789abcdef", Country: "DE", Ping: 50},
+	})
+
+	if v := testutil.ToFloat64(mc.playerPingMetric.WithLabelValues("", "10.0.0.0", "", "false", "DE", "", "")); v != 50 {
+		t.Errorf("expected ping series with masked labels, got %v", v)
+	}
+	if n := testutil.CollectAndCount(mc.playerPingMetric); n != 1 {
+		t.Errorf("expected 1 ping series, got %d", n)
+	}
+}
+
+func TestPrivacyDropMergesSeries(t *testing.T) {
+	mc := NewMetricsCollector(nil)
+	mc.SetPrivacy(Privacy{IP: PolicyTruncate, GUID: PolicyDrop, Name: PolicyDrop})
+	mc.InitPlayerPingMetrics()
+	mc.InitPlayerSessionMetrics()
+	mc.InitBansMetrics()
+
+	// players are identical after drop and truncate
+	mc.UpdatePlayerMetrics(&beparser.Players{
+		{Name: "Survivor", IP: "10.0.0.7", GUID: "0123456789abcdef0123456789abcdef", Country: "DE", Ping: 40},
+		{Name: "Bandit", IP: "10.0.0.8", GUID: "fedcba9876543210fedcba9876543210", Country: "DE", Ping: 60},
+	})
+	if n := testutil.CollectAndCount(mc.playerPingMetric); n != 1 {
+		t.Errorf("expected 1 ping series, got %d", n)
+	}
+	if v := testutil.ToFloat64(mc.playerPingMetric.WithLabelValues("", "10.0.0.0", "", "false", "DE", "", "")); v != 50 {
+		t.Errorf("expected average ping of merged players 50, got %v", v)
+	}
+
+	mc.UpdateSessionMetrics(&[]a2s.Player{
+		{Name: "Survivor", Duration: 10 * time.Minute, Score: 2},
+		{Name: "Bandit", Duration: 30 * time.Minute, Score: 4},
+	})
+	if v := testutil.ToFloat64(mc.playerSession.WithLabelValues("", "0")); v != 1200 {
+		t.Errorf("expected average session of merged players 1200, got %v", v)
+	}
+	if v := testutil.ToFloat64(mc.playerScore.WithLabelValues("", "0")); v != 3 {
+		t.Errorf("expected average score of merged players 3, got %v", v)
+	}
+
+	mc.UpdateBansMetrics(&beparser.Bans{
+		GUIDBans: beparser.BansGUID{
+			{GUID: "aaa", MinutesLeft: 30, Reason: "cheating"},
+			{GUID: "bbb", MinutesLeft: -1, Reason: "cheating"},
+			{GUID: "ccc", MinutesLeft: 60, Reason: "cheating"},
+		},
+		IPBans: beparser.BansIP{
+			{IP: "10.0.0.1", MinutesLeft: 10, Reason: "vpn"},
+			{IP: "10.0.0.2", MinutesLeft: 90, Reason: "vpn"},
+		},
+	})
+	if v := testutil.ToFloat64(mc.banGUIDTimeMetric.WithLabelValues("cheating", "")); v != -1 {
+		t.Errorf("expected permanent ban as longest of merged bans, got %v", v)
+	}
+	if v := testutil.ToFloat64(mc.banIPTimeMetric.WithLabelValues("vpn", "10.0.0.0", "", "", "")); v != 5400 {
+		t.Errorf("expected longest time left of merged bans 5400, got %v", v)
+	}
+	if v := testutil.ToFloat64(mc.banGUIDTotal); v != 3 {
+		t.Errorf("expected 3 GUID bans in total, got %v", v)
+	}
+}
@@ -0,0 +1,231 @@
+package bemetrics
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Schema is a version of metrics names and units
+type Schema string
+
+// metrics schemas
+const (
+	SchemaV1   Schema = "v1"   // original metrics names, kept for compatibility
+	SchemaV2   Schema = "v2"   // metrics in dayz_ namespace with base units and OpenMetrics names
+	SchemaBoth Schema = "both" // v1 and v2 metrics together, for migration of dashboards and alerts
+)
+
+// ParseSchema returns metrics schema by name, empty name means v1
+func ParseSchema(name string) (Schema, error) {
+	switch schema := Schema(name); schema {
+	case "":
+		return SchemaV1, nil
+	case SchemaV1, SchemaV2, SchemaBoth:
+		return schema, nil
+	}
+
+	return "", fmt.Errorf("unknown metrics schema %q, must be %s, %s or %s", name, SchemaV1, SchemaV2, SchemaBoth)
+}
+
+// SetSchema use for set metrics schema, must be called before metrics registration
+func (mc *MetricsCollector) SetSchema(schema Schema) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.schema = schema
+}
+
+// metric of v2 schema produced from v1 metric
+type renamedMetric struct {
+	from  prometheus.Collector // v1 metric
+	name  string               // v2 name
+	help  string               // v2 help
+	scale float64              // multiplier of values and buckets to base unit, 0 for keep values
+}
+
+// returns v2 schema for v1 metrics with other names or units, metrics not listed have the same names in both schemas
+func (mc *MetricsCollector) renamedMetrics() []renamedMetric {
+	return []renamedMetric{
+		// players
+		{from: mc.playerPingMetric, name: "dayz_player_ping_seconds", help: "Ping of players in seconds.", scale: 0.001},
+		{from: mc.playersPing, name: "dayz_players_ping_seconds", help: "Histogram of players ping in seconds, observed for all players on each update."},
+		{from: mc.countryPing, name: "dayz_players_country_ping_seconds", help: "Average ping of players by country in seconds."},
+		{from: mc.playersTotal, name: "dayz_players", help: "Count of players."},
+		{from: mc.playersOnline, name: "dayz_players_online", help: "Count of players online."},
+		{from: mc.playersInvalid, name: "dayz_players_invalid", help: "Count of invalid players."},
+		{from: mc.playersLobby, name: "dayz_players_lobby", help: "Count of players in lobby."},
+		// bans
+		{from: mc.banGUIDTimeMetric, name: "dayz_ban_guid_time_left_seconds", help: "Time left for GUID bans in seconds, -1 for permanent bans."},
+		{from: mc.banGUIDTotal, name: "dayz_bans_guid", help: "Count of GUID bans."},
+		{from: mc.banIPTimeMetric, name: "dayz_ban_ip_time_left_seconds", help: "Time left for IP bans in seconds, -1 for permanent bans."},
+		{from: mc.banIPTotal, name: "dayz_bans_ip", help: "Count of IP bans."},
+		{from: mc.bansAdded, name: "dayz_bans_added_total", help: "Total count of bans added to ban list."},
+		{from: mc.bansRemoved, name: "dayz_bans_removed_total", help: "Total count of bans removed from ban list, lifted or expired."},
+		{from: mc.bansExpiring, name: "dayz_bans_expiring", help: "Count of temporary bans expiring within configured window."},
+		{from: mc.bansByTerm, name: "dayz_bans", help: "Count of bans by type and term, permanent or temporary."},
+		{from: mc.banTimeLeft, name: "dayz_ban_time_left_seconds", help: "Time left for temporary bans in seconds."},
+		{from: mc.bansByReason, name: "dayz_bans_by_reason", help: "Count of bans by normalized reason, less common reasons are grouped as other."},
+		// server
+		{from: mc.serverPing, name: "dayz_server_query_ping_seconds", help: "Server A2S_INFO response time in seconds."},
+		{from: mc.serverPlayersOnline, name: "dayz_server_players_online", help: "Count of players online from A2S_INFO."},
+		{from: mc.serverPlayersSlots, name: "dayz_server_player_slots", help: "Count of players slots."},
+		{from: mc.serverPlayersQueue, name: "dayz_server_players_queue", help: "Count of players waiting in queue."},
+		{from: mc.serverTime, name: "dayz_server_game_time_seconds", help: "In-game time of day on server in seconds.", scale: 1e-9},
+		{from: mc.serverInfo, name: "dayz_server_settings_info", help: "Server settings parsed from A2S_INFO keywords, always 1."},
+		{from: mc.serverDayAccel, name: "dayz_server_time_acceleration_day_ratio", help: "Day time acceleration on server."},
+		{from: mc.serverNightAccel, name: "dayz_server_time_acceleration_night_ratio", help: "Night time acceleration on server."},
+		{from: mc.serverPassword, name: "dayz_server_password_protected", help: "Whether the server requires a password (1 for yes, 0 for no)."},
+		{from: mc.serverVAC, name: "dayz_server_vac_secured", help: "Whether the server is protected by VAC (1 for yes, 0 for no)."},
+		// sessions
+		{from: mc.sessionDuration, name: "dayz_query_players_session_seconds", help: "Session duration of players currently on server in seconds."},
+		{from: mc.sessionPlayers, name: "dayz_query_players", help: "Count of players in A2S_PLAYER response."},
+		{from: mc.playerSession, name: "dayz_query_player_session_seconds", help: "Session duration of player in seconds."},
+		{from: mc.playerScore, name: "dayz_query_player_score", help: "Score of player."},
+		// server messages
+		{from: mc.eventConnects, name: "dayz_player_connects_total", help: "Total count of players connections from server messages."},
+		{from: mc.eventDisconnects, name: "dayz_player_disconnects_total", help: "Total count of players disconnections from server messages."},
+		{from: mc.eventKicks, name: "dayz_player_kicks_total", help: "Total count of players kicks by reason."},
+		{from: mc.eventChat, name: "dayz_chat_messages_total", help: "Total count of chat messages by channel."},
+		{from: mc.eventAdminLogins, name: "dayz_rcon_admin_logins_total", help: "Total count of RCON admins logins."},
+	}
+}
+
+// schemaCollector exposes initialized v1 metrics under v2 names and units
+type schemaCollector struct {
+	metrics []renamedMetric
+}
+
+// Describe sends nothing, labels of rewritten metrics are known only on collect, so collector is unchecked
+func (c *schemaCollector) Describe(chan<- *prometheus.Desc) {}
+
+// Collect rewrites v1 metrics to v2 names and units
+func (c *schemaCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, renamed := range c.metrics {
+		metrics := make(chan prometheus.Metric)
+		go func() {
+			renamed.from.Collect(metrics)
+			close(metrics)
+		}()
+
+		for metric := range metrics {
+			if m, err := renamed.rewrite(metric); err == nil {
+				ch <- m
+			} else {
+				ch <- prometheus.NewInvalidMetric(prometheus.NewDesc(renamed.name, renamed.help, nil, nil), err)
+			}
+		}
+	}
+}
+
+// return v1 metric with v2 name and values in base unit
+func (r renamedMetric) rewrite(metric prometheus.Metric) (prometheus.Metric, error) {
+	var m dto.Metric
+	if err := metric.Write(&m); err != nil {
+		return nil, err
+	}
+
+	scale := r.scale
+	if scale == 0 {
+		scale = 1
+	}
+
+	names := make([]string, 0, len(m.GetLabel()))
+	values := make([]string, 0, len(m.GetLabel()))
+	for _, label := range m.GetLabel() {
+		names = append(names, label.GetName())
+		values = append(values, label.GetValue())
+	}
+	desc := prometheus.NewDesc(r.name, r.help, names, nil)
+
+	switch {
+	case m.Gauge != nil:
+		return prometheus.NewConstMetric(desc, prometheus.GaugeValue, m.GetGauge().GetValue()*scale, values...)
+	case m.Counter != nil:
+		return prometheus.NewConstMetric(desc, prometheus.CounterValue, m.GetCounter().GetValue()*scale, values...)
+	case m.Histogram != nil:
+		// histogram is passed as is to keep native histogram buckets, their exponential schema can't be scaled
+		h := m.GetHistogram()
+		if h.Schema != nil && scale != 1 {
+			return nil, fmt.Errorf("native histogram %s can't be scaled to base unit", r.name)
+		}
+		sum := h.GetSampleSum() * scale
+		h.SampleSum = &sum
+		for _, bucket := range h.GetBucket() {
+			upperBound := bucket.GetUpperBound() * scale
+			bucket.UpperBound = &upperBound
+		}
+		return &histogramMetric{desc: desc, labels: m.GetLabel(), histogram: h}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported type of metric %s", r.name)
+}
+
+// histogramMetric is a histogram rewritten to v2 name with classic and native buckets
+type histogramMetric struct {
+	desc      *prometheus.Desc
+	histogram *dto.Histogram
+	labels    []*dto.LabelPair
+}
+
+// Desc returns v2 metric description
+func (m *histogramMetric) Desc() *prometheus.Desc {
+	return m.desc
+}
+
+// Write writes histogram with labels of v1 metric
+func (m *histogramMetric) Write(out *dto.Metric) error {
+	out.Label = m.labels
+	out.Histogram = m.histogram
+
+	return nil
+}
+
+// returns initialized metrics to register for schema, v1 metrics renamed in v2 are collected by schemaCollector
+func (mc *MetricsCollector) schemaMetrics() []prometheus.Collector {
+	schema := mc.schema
+	if schema == "" {
+		schema = SchemaV1
+	}
+
+	renamed := make(map[prometheus.Collector]struct{})
+	v2 := &schemaCollector{}
+	for _, metric := range mc.renamedMetrics() {
+		if initialized(metric.from) {
+			renamed[metric.from] = struct{}{}
+			v2.metrics = append(v2.metrics, metric)
+		}
+	}
+
+	metrics := make([]prometheus.Collector, 0, len(renamed)+1)
+	for _, metric := range mc.getAllMetrics() {
+		if !initialized(metric) {
+			continue
+		}
+		if _, ok := renamed[metric]; ok && schema == SchemaV2 {
+			continue
+		}
+		metrics = append(metrics, metric)
+	}
+
+	if schema != SchemaV1 && len(v2.metrics) > 0 {
+		metrics = append(metrics, v2)
+	}
+
+	return metrics
+}
+
+// check metric vector is initialized
+func initialized(metric prometheus.Collector) bool {
+	switch vec := metric.(type) {
+	case *prometheus.GaugeVec:
+		return vec != nil
+	case *prometheus.HistogramVec:
+		return vec != nil
+	case *prometheus.CounterVec:
+		return vec != nil
+	}
+
+	return false
+}
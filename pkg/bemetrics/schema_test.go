@@ -0,0 +1,128 @@
+package bemetrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/woozymasta/a2s/pkg/a2s"
+	"github.com/woozymasta/a2s/pkg/a3sb"
+	"github.com/woozymasta/bercon-cli/pkg/beparser"
+)
+
+func newSchemaCollector(t *testing.T, schema Schema) *prometheus.Registry {
+	t.Helper()
+
+	mc := NewMetricsCollector(Labels{{Key: "server", Value: "test"}})
+	mc.InitServerMetrics()
+	mc.InitPlayerMetrics()
+	mc.InitPlayerPingMetrics()
+	mc.InitPlayerTrackerMetrics()
+	mc.InitModsMetrics()
+	mc.SetSchema(schema)
+
+	reg := prometheus.NewRegistry()
+	mc.RegisterMetricsWith(reg)
+
+	mc.UpdateServerMetrics(&a2s.Info{Keywords: []string{"battleye", "12:30"}})
+	mc.UpdatePlayerMetrics(&beparser.Players{{Name: "Survivor", GUID: "A", Ping: 80, Valid: true}})
+	mc.UpdateModsMetrics(&a3sb.Rules{Mods: []a3sb.Mod{{Name: "Community Framework", ID: 1559212036}}})
+
+	return reg
+}
+
+func TestSchemaV2(t *testing.T) {
+	reg := newSchemaCollector(t, SchemaV2)
+
+	expected := `
+# HELP dayz_player_ping_seconds Ping of players in seconds.
+# TYPE dayz_player_ping_seconds gauge
+dayz_player_ping_seconds{as_org="",asn="",country="",guid="A",ip="",lobby="false",name="Survivor",server="test"} 0.08
+# HELP dayz_server_game_time_seconds In-game time of day on server in seconds.
+# TYPE dayz_server_game_time_seconds gauge
+dayz_server_game_time_seconds{server="test"} 45000
+# HELP dayz_players Count of players.
+# TYPE dayz_players gauge
+dayz_players{server="test"} 1
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(expected),
+		"dayz_player_ping_seconds", "dayz_server_game_time_seconds", "dayz_players"); err != nil {
+		t.Error(err)
+	}
+
+	count, err := testutil.GatherAndCount(reg, "bercon_player_ping_seconds", "a2s_info_time", "bercon_players_total")
+	if err != nil || count != 0 {
+		t.Errorf("expected no v1 metrics, got %d, %v", count, err)
+	}
+
+	// metrics with the same name in both schemas are kept
+	if count, err := testutil.GatherAndCount(reg, "dayz_players_unique_today", "dayz_server_mods"); err != nil || count != 2 {
+		t.Errorf("expected unique players and mods metrics, got %d, %v", count, err)
+	}
+
+	// histogram buckets are kept
+	if count, err := testutil.GatherAndCount(reg, "dayz_players_ping_seconds"); err != nil || count != 1 {
+		t.Errorf("expected ping histogram, got %d, %v", count, err)
+	}
+}
+
+func TestSchemaBoth(t *testing.T) {
+	reg := newSchemaCollector(t, SchemaBoth)
+
+	count, err := testutil.GatherAndCount(reg, "bercon_player_ping_seconds", "dayz_player_ping_seconds")
+	if err != nil || count != 2 {
+		t.Errorf("expected v1 and v2 ping series, got %d, %v", count, err)
+	}
+
+	// metrics with the same name in both schemas are exposed once
+	if count, err := testutil.GatherAndCount(reg, "dayz_server_mods"); err != nil || count != 1 {
+		t.Errorf("expected single mods metric, got %d, %v", count, err)
+	}
+
+	if _, err := ParseSchema("v3"); err == nil {
+		t.Error("expected error for unknown schema")
+	}
+	if schema, err := ParseSchema(""); err != nil || schema != SchemaV1 {
+		t.Errorf("expected v1 schema by default, got %q, %v", schema, err)
+	}
+}
+
+func TestSchemaV2NativeHistogram(t *testing.T) {
+	mc := NewMetricsCollector(Labels{{Key: "server", Value: "test"}})
+	mc.SetPingNativeHistogram(true)
+	mc.InitPlayerMetrics()
+	mc.SetSchema(SchemaV2)
+
+	reg := prometheus.NewRegistry()
+	mc.RegisterMetricsWith(reg)
+	mc.UpdatePlayerMetrics(&beparser.Players{
+		{Name: "Survivor", GUID: "A", Ping: 80, Valid: true},
+		{Name: "Bandit", GUID: "B", Ping: 120, Valid: true},
+	})
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, family := range families {
+		if family.GetName() != "dayz_players_ping_seconds" {
+			continue
+		}
+
+		h := family.GetMetric()[0].GetHistogram()
+		if h.Schema == nil || len(h.GetPositiveSpan()) == 0 {
+			t.Error("expected native histogram buckets in v2 schema")
+		}
+		if len(h.GetBucket()) == 0 {
+			t.Error("expected classic histogram buckets in v2 schema")
+		}
+		if h.GetSampleCount() != 2 || h.GetSampleSum() != 0.2 {
+			t.Errorf("unexpected histogram count %d and sum %v", h.GetSampleCount(), h.GetSampleSum())
+		}
+		return
+	}
+
+	t.Error("players ping histogram not found")
+}
@@ -0,0 +1,177 @@
+package bemetrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/woozymasta/bercon-cli/pkg/beparser"
+)
+
+// playerTracker keeps players sessions between bercon players snapshots by GUID
+type playerTracker struct {
+	now      func() time.Time     // clock, replaced in tests
+	sessions map[string]time.Time // session start time by player GUID
+	partial  map[string]struct{}  // players online in baseline snapshot with unknown session start
+	unique   map[string]struct{}  // unique players GUID seen in current day
+	day      string               // current day in UTC for unique players
+	mu       sync.Mutex           // guards tracker state
+	seen     bool                 // flag for first snapshot used as baseline
+}
+
+// InitPlayerTrackerMetrics initialize bercon players sessions tracking metrics
+func (mc *MetricsCollector) InitPlayerTrackerMetrics() {
+	labels := mc.customLabels.Keys()
+
+	if mc.tracker == nil {
+		mc.tracker = &playerTracker{now: time.Now}
+		mc.tracker.reset()
+	}
+
+	if mc.playerJoins == nil {
+		mc.playerJoins = prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "dayz_player_joins_total",
+				Help: "Total count of players joins.",
+			},
+			labels,
+		)
+	}
+
+	if mc.playerLeaves == nil {
+		mc.playerLeaves = prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "dayz_player_leaves_total",
+				Help: "Total count of players leaves.",
+			},
+			labels,
+		)
+	}
+
+	if mc.playerSessions == nil {
+		mc.playerSessions = prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "dayz_player_session_duration_seconds",
+				Help:    "Duration of finished players sessions in seconds.",
+				Buckets: sessionBuckets,
+			},
+			labels,
+		)
+	}
+
+	if mc.playersUnique == nil {
+		mc.playersUnique = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "dayz_players_unique_today",
+				Help: "Count of unique players seen on server since UTC midnight.",
+			},
+			labels,
+		)
+	}
+}
+
+// update players sessions tracking metrics with difference from previous snapshot
+func (mc *MetricsCollector) trackPlayers(players []beparser.Player, values []string) {
+	if mc.tracker == nil {
+		return
+	}
+
+	joins, sessions := mc.tracker.update(players)
+
+	if mc.playerJoins != nil {
+		mc.playerJoins.WithLabelValues(values...).Add(float64(joins))
+	}
+
+	if mc.playerLeaves != nil {
+		mc.playerLeaves.WithLabelValues(values...).Add(float64(len(sessions)))
+	}
+
+	if mc.playerSessions != nil {
+		observer := mc.playerSessions.WithLabelValues(values...)
+		for _, session := range sessions {
+			if session > 0 {
+				observer.Observe(session.Seconds())
+			}
+		}
+	}
+
+	if mc.playersUnique != nil {
+		mc.playersUnique.WithLabelValues(values...).Set(float64(mc.tracker.uniqueCount()))
+	}
+}
+
+// diff snapshot with known sessions, return count of joins and durations of finished sessions,
+// duration is zero for sessions started before first snapshot
+func (t *playerTracker) update(players []beparser.Player) (int, []time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	if day := now.UTC().Format(time.DateOnly); day != t.day {
+		t.day = day
+		clear(t.unique)
+	}
+
+	online := make(map[string]struct{}, len(players))
+	joins := 0
+
+	for _, player := range players {
+		if player.GUID == "" {
+			continue
+		}
+		online[player.GUID] = struct{}{}
+		t.unique[player.GUID] = struct{}{}
+
+		if _, ok := t.sessions[player.GUID]; ok {
+			continue
+		}
+
+		t.sessions[player.GUID] = now
+		if t.seen {
+			joins++
+		} else {
+			t.partial[player.GUID] = struct{}{}
+		}
+	}
+
+	var sessions []time.Duration
+	for guid, start := range t.sessions {
+		if _, ok := online[guid]; ok {
+			continue
+		}
+
+		var duration time.Duration
+		if _, ok := t.partial[guid]; !ok {
+			duration = now.Sub(start)
+		}
+		sessions = append(sessions, duration)
+
+		delete(t.sessions, guid)
+		delete(t.partial, guid)
+	}
+
+	t.seen = true
+
+	return joins, sessions
+}
+
+// return count of unique players seen in current day
+func (t *playerTracker) uniqueCount() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return len(t.unique)
+}
+
+// forget all sessions, next snapshot is used as new baseline
+func (t *playerTracker) reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.sessions = make(map[string]time.Time)
+	t.partial = make(map[string]struct{})
+	if t.unique == nil {
+		t.unique = make(map[string]struct{})
+	}
+	t.seen = false
+}